@@ -9,6 +9,7 @@ import (
 	"os/signal"
 	"syscall"
 
+	"alarm-service/internal/alarm"
 	"alarm-service/internal/app"
 )
 
@@ -24,6 +25,8 @@ func main() {
 	hairTrigger := flag.Bool("hair-trigger", false, "Enable hair trigger mode (immediate short alarm on first motion)")
 	hairTriggerDuration := flag.Int("hair-trigger-duration", 3, "Hair trigger alarm duration in seconds")
 	l1Cooldown := flag.Int("l1-cooldown", 5, "Level 1 cooldown duration in seconds")
+	lostModeKeyFile := flag.String("lost-mode-key-file", alarm.DefaultLostModeKeyFile, "File holding the key lost mode commands are signed with (mode 0600)")
+	lostModeStateFile := flag.String("lost-mode-state-file", alarm.DefaultLostModeStateFile, "File the last accepted lost mode command is kept in")
 	versionFlag := flag.Bool("version", false, "Print version and exit")
 	flag.Parse()
 
@@ -97,6 +100,8 @@ func main() {
 		HairTriggerDurationFlagSet: hairTriggerDurationFlagSet,
 		L1Cooldown:                 *l1Cooldown,
 		L1CooldownFlagSet:          l1CooldownFlagSet,
+		LostModeKeyFile:            *lostModeKeyFile,
+		LostModeStateFile:          *lostModeStateFile,
 	})
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -0,0 +1,223 @@
+package alarm
+
+import (
+	"sync"
+	"time"
+)
+
+// Default horn duty-cycle limits. A full L2 episode (maxLevel2Cycles ×
+// alarm-duration at 50% duty) spends about 30 s of horn on-time, so one
+// real episode fits comfortably in an hour while a false-trigger loop
+// through the post-alarm cooldown runs dry well before morning.
+const (
+	defaultHornMaxPerHour  = 120 * time.Second
+	defaultHornMaxPerNight = 300 * time.Second
+	defaultHornMinRest     = 5 * time.Second
+)
+
+// Default night window for the per-night budget, minutes since midnight.
+const (
+	defaultHornNightStart = 22 * 60
+	defaultHornNightEnd   = 7 * 60
+)
+
+// Reasons the horn budget refuses on-time, published as horn-budget-exhausted.
+const (
+	hornBudgetOK      = ""
+	hornBudgetHour    = "hour"
+	hornBudgetNight   = "night"
+	hornBudgetResting = "rest"
+)
+
+// hornPulse is one stretch of horn on-time.
+type hornPulse struct {
+	at       time.Time
+	duration time.Duration
+}
+
+// hornBudget tracks horn on-time to protect the horn from overheating and
+// the neighbours from an all-night siren: at most maxPerHour on-time in any
+// rolling hour, maxPerNight within the night window (local time, may wrap
+// past midnight), and minRest of silence between bursts. Safe for
+// concurrent use.
+type hornBudget struct {
+	mu          sync.Mutex
+	maxPerHour  time.Duration
+	maxPerNight time.Duration
+	minRest     time.Duration
+	nightStart  int // minutes since midnight
+	nightEnd    int
+	pulses      []hornPulse
+	// night on-time restored after a restart; counts only against the
+	// night window starting at restoredStart, never the rolling hour
+	restoredStart time.Time
+	restoredNight time.Duration
+	lastBurst     time.Time // end of the last burst that used the horn
+	inBurst       bool      // current burst has used the horn
+}
+
+func newHornBudget() *hornBudget {
+	return &hornBudget{
+		maxPerHour:  defaultHornMaxPerHour,
+		maxPerNight: defaultHornMaxPerNight,
+		minRest:     defaultHornMinRest,
+		nightStart:  defaultHornNightStart,
+		nightEnd:    defaultHornNightEnd,
+	}
+}
+
+// setNight updates the night window, in minutes since midnight.
+func (b *hornBudget) setNight(start, end int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nightStart = start
+	b.nightEnd = end
+}
+
+// setLimits updates the limits. Zero or negative values keep the current limit.
+func (b *hornBudget) setLimits(perHour, perNight, minRest time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if perHour > 0 {
+		b.maxPerHour = perHour
+	}
+	if perNight > 0 {
+		b.maxPerNight = perNight
+	}
+	if minRest > 0 {
+		b.minRest = minRest
+	}
+}
+
+// allow reports whether a horn pulse of duration d may start now, and if not
+// which limit is in the way.
+func (b *hornBudget) allow(now time.Time, d time.Duration) (bool, string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	reason := b.exhaustedUnsafe(now, d)
+	return reason == hornBudgetOK, reason
+}
+
+// record books a horn pulse that started at now.
+func (b *hornBudget) record(now time.Time, d time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.pulses = append(b.pulses, hornPulse{at: now, duration: d})
+	b.inBurst = true
+	b.pruneUnsafe(now)
+}
+
+// endBurst marks the end of an alarm burst; the rest period starts now if
+// the burst used the horn at all.
+func (b *hornBudget) endBurst(now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.inBurst {
+		b.lastBurst = now
+		b.inBurst = false
+	}
+}
+
+// restoreNight books night on-time used before a restart against the window
+// starting at windowStart. It is kept apart from the pulses so it doesn't
+// count against the rolling hour. Ignored unless that window may still be
+// running.
+func (b *hornBudget) restoreNight(now, windowStart time.Time, used time.Duration) {
+	if used <= 0 || windowStart.After(now) || now.Sub(windowStart) >= 24*time.Hour {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.restoredStart = windowStart
+	b.restoredNight = used
+	b.pruneUnsafe(now)
+}
+
+// nightUsage returns the start of the night window now falls in and the
+// on-time used in it, or the zero time outside the window.
+func (b *hornBudget) nightUsage(now time.Time) (time.Time, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	start, ok := b.nightWindowUnsafe(now)
+	if !ok {
+		return time.Time{}, 0
+	}
+	_, night := b.usedUnsafe(now)
+	return start, night
+}
+
+// remaining returns the on-time left in the rolling hour and the night, and
+// the limit currently in the way ("" if the horn may sound).
+func (b *hornBudget) remaining(now time.Time) (time.Duration, time.Duration, string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	hour, night := b.usedUnsafe(now)
+	return max(b.maxPerHour-hour, 0), max(b.maxPerNight-night, 0), b.exhaustedUnsafe(now, 0)
+}
+
+func (b *hornBudget) exhaustedUnsafe(now time.Time, d time.Duration) string {
+	hour, night := b.usedUnsafe(now)
+	_, inNight := b.nightWindowUnsafe(now)
+	switch {
+	case inNight && (night+d > b.maxPerNight || night >= b.maxPerNight):
+		return hornBudgetNight
+	case hour+d > b.maxPerHour || hour >= b.maxPerHour:
+		return hornBudgetHour
+	case !b.inBurst && !b.lastBurst.IsZero() && now.Sub(b.lastBurst) < b.minRest:
+		return hornBudgetResting
+	}
+	return hornBudgetOK
+}
+
+// usedUnsafe returns the on-time used in the rolling hour and in the
+// current night window (zero outside it).
+func (b *hornBudget) usedUnsafe(now time.Time) (hour, night time.Duration) {
+	hourStart := now.Add(-time.Hour)
+	nightStart, inNight := b.nightWindowUnsafe(now)
+	for _, p := range b.pulses {
+		if p.at.After(hourStart) {
+			hour += p.duration
+		}
+		if inNight && !p.at.Before(nightStart) {
+			night += p.duration
+		}
+	}
+	if inNight && b.restoredStart.Equal(nightStart) {
+		night += b.restoredNight
+	}
+	return hour, night
+}
+
+// pruneUnsafe drops pulses that no longer count against either window.
+func (b *hornBudget) pruneUnsafe(now time.Time) {
+	cutoff := now.Add(-time.Hour)
+	if start, ok := b.nightWindowUnsafe(now); ok && start.Before(cutoff) {
+		cutoff = start
+	}
+	keep := b.pulses[:0]
+	for _, p := range b.pulses {
+		if !p.at.Before(cutoff) {
+			keep = append(keep, p)
+		}
+	}
+	b.pulses = keep
+	if start, ok := b.nightWindowUnsafe(now); !ok || !start.Equal(b.restoredStart) {
+		b.restoredStart = time.Time{}
+		b.restoredNight = 0
+	}
+}
+
+// nightWindowUnsafe returns the start of the night window now falls in, in
+// now's location, and whether it falls in one at all.
+func (b *hornBudget) nightWindowUnsafe(now time.Time) (time.Time, bool) {
+	minute := now.Hour()*60 + now.Minute()
+	start := time.Date(now.Year(), now.Month(), now.Day(), b.nightStart/60, b.nightStart%60, 0, 0, now.Location())
+	if b.nightStart < b.nightEnd {
+		return start, minute >= b.nightStart && minute < b.nightEnd
+	}
+	// Wraps past midnight: before the end, the window began yesterday.
+	if minute < b.nightEnd {
+		return start.AddDate(0, 0, -1), true
+	}
+	return start, minute >= b.nightStart
+}
@@ -0,0 +1,129 @@
+package alarm
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHornBudget_HourLimit(t *testing.T) {
+	b := newHornBudget()
+	b.setLimits(2*time.Second, time.Hour, time.Second)
+	now := time.Date(2026, 1, 10, 23, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 5; i++ {
+		if ok, reason := b.allow(now, 400*time.Millisecond); !ok {
+			t.Fatalf("pulse %d refused: %s", i, reason)
+		}
+		b.record(now, 400*time.Millisecond)
+		now = now.Add(800 * time.Millisecond)
+	}
+
+	if ok, reason := b.allow(now, 400*time.Millisecond); ok || reason != hornBudgetHour {
+		t.Errorf("expected hour budget exhausted, got ok=%v reason=%q", ok, reason)
+	}
+
+	// The rolling hour frees the budget again.
+	now = now.Add(time.Hour)
+	if ok, reason := b.allow(now, 400*time.Millisecond); !ok {
+		t.Errorf("expected budget available an hour later, got %q", reason)
+	}
+}
+
+func TestHornBudget_NightLimitResetsNextNight(t *testing.T) {
+	b := newHornBudget()
+	b.setLimits(time.Hour, time.Second, time.Second)
+	now := time.Date(2026, 1, 10, 23, 0, 0, 0, time.UTC)
+
+	b.record(now, time.Second)
+	b.endBurst(now)
+
+	// Still the same night after midnight.
+	now = now.Add(2 * time.Hour)
+	if ok, reason := b.allow(now, 400*time.Millisecond); ok || reason != hornBudgetNight {
+		t.Errorf("expected night budget exhausted, got ok=%v reason=%q", ok, reason)
+	}
+
+	now = time.Date(2026, 1, 11, 22, 0, 1, 0, time.UTC)
+	if ok, reason := b.allow(now, 400*time.Millisecond); !ok {
+		t.Errorf("expected night budget reset the next night, got %q", reason)
+	}
+}
+
+func TestHornBudget_NightLimitOnlyInWindow(t *testing.T) {
+	b := newHornBudget()
+	b.setLimits(time.Hour, time.Second, time.Second)
+	b.setNight(22*60, 7*60)
+	now := time.Date(2026, 1, 10, 14, 0, 0, 0, time.UTC)
+
+	b.record(now, 2*time.Second)
+	b.endBurst(now)
+
+	now = now.Add(time.Minute)
+	if ok, reason := b.allow(now, 400*time.Millisecond); !ok {
+		t.Errorf("expected no night cap during the day, got %q", reason)
+	}
+	if start, used := b.nightUsage(now); !start.IsZero() || used != 0 {
+		t.Errorf("expected no night usage outside the window, got %v %v", start, used)
+	}
+
+	// Daytime use doesn't count against the night.
+	now = time.Date(2026, 1, 10, 22, 30, 0, 0, time.UTC)
+	if ok, reason := b.allow(now, 400*time.Millisecond); !ok {
+		t.Errorf("expected fresh night budget, got %q", reason)
+	}
+}
+
+func TestHornBudget_RestoreNight(t *testing.T) {
+	b := newHornBudget()
+	b.setLimits(time.Hour, 10*time.Second, time.Second)
+	start := time.Date(2026, 1, 10, 22, 0, 0, 0, time.UTC)
+	now := time.Date(2026, 1, 11, 3, 0, 0, 0, time.UTC)
+
+	b.restoreNight(now, start, 10*time.Second)
+	if ok, reason := b.allow(now, 400*time.Millisecond); ok || reason != hornBudgetNight {
+		t.Errorf("expected restored night budget exhausted, got ok=%v reason=%q", ok, reason)
+	}
+	if got, used := b.nightUsage(now); !got.Equal(start) || used != 10*time.Second {
+		t.Errorf("nightUsage = %v %v, want %v 10s", got, used, start)
+	}
+
+	// A window from a previous night is ignored.
+	b = newHornBudget()
+	b.setLimits(time.Hour, 10*time.Second, time.Second)
+	b.restoreNight(now, start.AddDate(0, 0, -2), 10*time.Second)
+	if ok, reason := b.allow(now, 400*time.Millisecond); !ok {
+		t.Errorf("expected stale night usage ignored, got %q", reason)
+	}
+
+	// Restored night usage doesn't count against the rolling hour.
+	b = newHornBudget()
+	b.setLimits(10*time.Second, time.Minute, time.Second)
+	now = start.Add(30 * time.Minute)
+	b.restoreNight(now, start, 10*time.Second)
+	if ok, reason := b.allow(now, 400*time.Millisecond); !ok {
+		t.Errorf("expected hour budget untouched by restored night usage, got %q", reason)
+	}
+	if _, used := b.nightUsage(now); used != 10*time.Second {
+		t.Errorf("nightUsage = %v, want 10s", used)
+	}
+}
+
+func TestHornBudget_MinRestBetweenBursts(t *testing.T) {
+	b := newHornBudget()
+	b.setLimits(time.Hour, time.Hour, 10*time.Second)
+	now := time.Date(2026, 1, 10, 23, 0, 0, 0, time.UTC)
+
+	b.record(now, 400*time.Millisecond)
+	// Within a burst the rest period doesn't apply.
+	if ok, _ := b.allow(now.Add(time.Second), 400*time.Millisecond); !ok {
+		t.Error("expected pulses within a burst to be allowed")
+	}
+	b.endBurst(now.Add(2 * time.Second))
+
+	if ok, reason := b.allow(now.Add(5*time.Second), 400*time.Millisecond); ok || reason != hornBudgetResting {
+		t.Errorf("expected rest period, got ok=%v reason=%q", ok, reason)
+	}
+	if ok, reason := b.allow(now.Add(13*time.Second), 400*time.Millisecond); !ok {
+		t.Errorf("expected horn allowed after rest, got %q", reason)
+	}
+}
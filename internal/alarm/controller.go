@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -15,21 +17,41 @@ import (
 type RuntimeCommander interface {
 	RuntimeArm()
 	RuntimeDisarm()
+	Silence(seconds int)
+	ManualStart(seconds int)
+	ManualStop()
+	LostMode(enabled bool)
 }
 
 // Controller manages alarm activation (horn + hazard lights)
 type Controller struct {
-	ipc         *ipc.Client
-	alarmPub    *ipc.HashPublisher
-	settingsPub *ipc.HashPublisher
-	cmdHandler  *ipc.QueueHandler[string]
-	commander   RuntimeCommander
-	ctx         context.Context
-	cancel      context.CancelFunc
-	log         *slog.Logger
-	mu          sync.Mutex
-	active      bool
-	hornEnabled atomic.Bool
+	ipc           *ipc.Client
+	rpc           *ipc.Client
+	alarmPub      *ipc.HashPublisher
+	settingsPub   *ipc.HashPublisher
+	cmdHandler    *ipc.QueueHandler[string]
+	rpcServer     *ipc.CallServer
+	commander     RuntimeCommander
+	ctx           context.Context
+	cancel        context.CancelFunc
+	blinkCancel   context.CancelFunc
+	locateCancel  context.CancelFunc
+	log           *slog.Logger
+	mu            sync.Mutex
+	active        bool
+	blinkerOwned  bool
+	priorBlinker  string
+	lastLocate    time.Time
+	budget        *hornBudget
+	reenableTimer *time.Timer
+	reenableGen   uint64
+	// enabledWatcher follows alarm.enabled for the pending re-enable
+	enabledWatcher *ipc.HashWatcher
+	hornEnabled    atomic.Bool
+	// lostModeKeyFile holds the key lost mode commands are signed with
+	lostModeKeyFile string
+	// lostModeStateFile holds the last accepted lost mode command
+	lostModeStateFile string
 }
 
 // NewController creates a new alarm controller using redis-ipc
@@ -51,8 +73,18 @@ func NewController(redisAddr string, hornEnabled bool, log *slog.Logger) (*Contr
 		ctx:         ctx,
 		log:         log,
 		active:      false,
+		budget:      newHornBudget(),
 	}
 	c.hornEnabled.Store(hornEnabled)
+	c.restoreHornBudget()
+	c.publishHornBudget()
+	c.restoreReenable()
+
+	c.enabledWatcher = client.NewHashWatcher("settings").OnField("alarm.enabled", c.onEnabledChanged)
+	if err := c.enabledWatcher.Start(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to watch alarm.enabled: %w", err)
+	}
 
 	c.cmdHandler = ipc.HandleRequests(client, "scooter:alarm", func(cmd string) error {
 		c.log.Info("received alarm command", "command", cmd)
@@ -60,15 +92,40 @@ func NewController(redisAddr string, hornEnabled bool, log *slog.Logger) (*Contr
 		return nil
 	})
 
+	if err := c.startRPCServer(redisAddr); err != nil {
+		c.cmdHandler.Stop()
+		c.enabledWatcher.Stop()
+		client.Close()
+		return nil, err
+	}
+
 	return c, nil
 }
 
 // Close closes the controller
 func (c *Controller) Close() error {
 	c.Stop()
+	// Lets the next instance tell a restart mid-episode from a crash.
+	if err := c.alarmPub.Set("clean-shutdown", "true", ipc.Sync()); err != nil {
+		c.log.Error("failed to mark clean shutdown", "error", err)
+	}
+	c.mu.Lock()
+	if c.reenableTimer != nil {
+		c.reenableTimer.Stop()
+	}
+	c.mu.Unlock()
+	if c.enabledWatcher != nil {
+		c.enabledWatcher.Stop()
+	}
 	if c.cmdHandler != nil {
 		c.cmdHandler.Stop()
 	}
+	if c.rpcServer != nil {
+		c.rpcServer.Stop()
+	}
+	if c.rpc != nil {
+		c.rpc.Close()
+	}
 	return c.ipc.Close()
 }
 
@@ -77,6 +134,17 @@ func (c *Controller) SetCommander(commander RuntimeCommander) {
 	c.commander = commander
 }
 
+// SetLostModeKeyFile sets the file lost mode commands are verified against.
+func (c *Controller) SetLostModeKeyFile(path string) {
+	c.lostModeKeyFile = path
+}
+
+// SetLostModeStateFile sets the file the last accepted lost mode command is
+// kept in.
+func (c *Controller) SetLostModeStateFile(path string) {
+	c.lostModeStateFile = path
+}
+
 // SetHornEnabled updates the horn enabled setting
 func (c *Controller) SetHornEnabled(enabled bool) {
 	c.hornEnabled.Store(enabled)
@@ -88,8 +156,130 @@ func (c *Controller) SetHornEnabled(enabled bool) {
 	}
 }
 
+// SetHornBudget updates the horn duty-cycle limits. Zero keeps a limit unchanged.
+func (c *Controller) SetHornBudget(maxPerHour, maxPerNight, minRest time.Duration) {
+	c.budget.setLimits(maxPerHour, maxPerNight, minRest)
+	c.log.Info("horn budget updated", "max_per_hour", maxPerHour, "max_per_night", maxPerNight, "min_rest", minRest)
+	c.publishHornBudget()
+}
+
+// SetHornNight updates the night window of the horn budget, in minutes
+// since midnight.
+func (c *Controller) SetHornNight(start, end int) {
+	c.budget.setNight(start, end)
+	c.log.Info("horn night window updated", "start", start, "end", end)
+	c.publishHornBudget()
+}
+
+// publishHornBudget publishes the remaining horn on-time (seconds), the
+// limit currently muting the horn, if any, and the night on-time used so
+// far so a restart doesn't hand out a fresh night budget.
+func (c *Controller) publishHornBudget() {
+	now := time.Now()
+	hour, night, exhausted := c.budget.remaining(now)
+	nightStart, nightUsed := c.budget.nightUsage(now)
+	start := ""
+	if !nightStart.IsZero() {
+		start = strconv.FormatInt(nightStart.Unix(), 10)
+	}
+	if _, err := c.alarmPub.SetManyIfChanged(map[string]any{
+		"horn-budget-hour":      int(hour.Seconds()),
+		"horn-budget-night":     int(night.Seconds()),
+		"horn-budget-exhausted": exhausted,
+		"horn-night-start":      start,
+		"horn-night-used":       int(nightUsed.Seconds()),
+	}); err != nil {
+		c.log.Error("failed to publish horn budget", "error", err)
+	}
+}
+
+// restoreHornBudget books the night on-time a previous instance published.
+func (c *Controller) restoreHornBudget() {
+	fields, err := c.alarmPub.GetAll()
+	if err != nil || fields["horn-night-start"] == "" {
+		return
+	}
+	start, err := strconv.ParseInt(fields["horn-night-start"], 10, 64)
+	if err != nil {
+		c.log.Warn("malformed horn-night-start, ignoring", "value", fields["horn-night-start"])
+		return
+	}
+	used, err := strconv.Atoi(fields["horn-night-used"])
+	if err != nil {
+		return
+	}
+	c.budget.restoreNight(time.Now(), time.Unix(start, 0), time.Duration(used)*time.Second)
+	c.log.Info("restored horn night budget", "night_start", time.Unix(start, 0), "used_seconds", used)
+}
+
+// episodeStatuses are the FSM statuses in which the previous instance may
+// have had horn or blinker running, including the silent gaps between L2
+// cycles where alarm-active is false.
+var episodeStatuses = map[string]bool{
+	"level-1-triggered": true,
+	"level-2-triggered": true,
+	"manual-alarm":      true,
+}
+
+// ReconcileStaleOutputs cleans up after a previous instance that died with
+// an output running or mid-episode: alarm-active (or locate-active) is
+// still set in the alarm hash, or the last published status is an episode
+// and Close never marked the shutdown clean. Forces the horn off, hands the
+// blinker back in the state the alarm took it in, records the unclean
+// shutdown in the alarm hash and returns the status the previous instance
+// last published. Returns "" when the previous shutdown was clean.
+func (c *Controller) ReconcileStaleOutputs() (string, error) {
+	fields, err := c.ipc.HGetAll("alarm")
+	if err != nil {
+		return "", fmt.Errorf("failed to read alarm hash: %w", err)
+	}
+	status := fields["status"]
+	outputs := fields["alarm-active"] == "true" || fields["locate-active"] == "true"
+	midEpisode := episodeStatuses[status] && fields["clean-shutdown"] != "true"
+	if err := c.alarmPub.Set("clean-shutdown", "false"); err != nil {
+		c.log.Error("failed to reset clean-shutdown", "error", err)
+	}
+	if !outputs && !midEpisode {
+		return "", nil
+	}
+
+	c.log.Warn("unclean shutdown with alarm outputs or episode running, releasing horn and blinker",
+		"previous_status", status)
+
+	c.ipc.LPush("scooter:horn", "off")
+	if prior := fields["blinker-prior"]; prior != "" {
+		c.mu.Lock()
+		c.blinkerOwned = true
+		c.priorBlinker = prior
+		c.releaseBlinkerUnsafe()
+		c.mu.Unlock()
+	}
+
+	if err := c.alarmPub.SetMany(map[string]any{
+		"alarm-active":            "false",
+		"locate-active":           "false",
+		"unclean-shutdown":        time.Now().Unix(),
+		"unclean-shutdown-status": status,
+	}); err != nil {
+		c.log.Error("failed to record unclean shutdown", "error", err)
+	}
+
+	return status, nil
+}
+
 // Start starts the alarm for the specified duration
 func (c *Controller) Start(duration time.Duration) error {
+	return c.start(duration, true)
+}
+
+// StartHazardsOnly runs the alarm for the specified duration with the horn
+// muted regardless of the horn setting (e.g. while the alarm is silenced).
+func (c *Controller) StartHazardsOnly(duration time.Duration) error {
+	return c.start(duration, false)
+}
+
+// start starts the alarm, honking only if honk is set and the horn is enabled
+func (c *Controller) start(duration time.Duration, honk bool) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -97,20 +287,23 @@ func (c *Controller) Start(duration time.Duration) error {
 		c.log.Warn("alarm already active, stopping previous alarm")
 		c.stopUnsafe()
 	}
+	c.endBlinkUnsafe()
+	c.endLocateUnsafe()
 
-	c.log.Info("starting alarm", "duration", duration)
+	c.log.Info("starting alarm", "duration", duration, "honk", honk)
 
 	ctx, cancel := context.WithCancel(c.ctx)
 	c.cancel = cancel
 	c.active = true
 
+	c.takeBlinkerUnsafe()
 	if _, err := c.ipc.LPush("scooter:blinker", "both"); err != nil {
 		c.log.Error("failed to activate hazard lights", "error", err)
 	}
 
 	c.alarmPub.Set("alarm-active", "true")
 
-	go c.runHornPattern(ctx, duration)
+	go c.runHornPattern(ctx, duration, honk)
 
 	return nil
 }
@@ -122,9 +315,14 @@ func (c *Controller) Stop() error {
 	return c.stopUnsafe()
 }
 
-// stopUnsafe stops the alarm without locking (internal use)
+// stopUnsafe stops the alarm without locking (internal use). A running
+// hazard blink or locate pattern is cut short as well.
 func (c *Controller) stopUnsafe() error {
+	c.endBlinkUnsafe()
+	c.endLocateUnsafe()
+
 	if !c.active {
+		c.releaseBlinkerUnsafe()
 		return nil
 	}
 
@@ -137,10 +335,13 @@ func (c *Controller) stopUnsafe() error {
 	// Always turn the horn off when the alarm stops, even if honking was
 	// disabled mid-siren — otherwise the last "on" stays energized.
 	c.ipc.LPush("scooter:horn", "off")
-	c.ipc.LPush("scooter:blinker", "off")
+	c.releaseBlinkerUnsafe()
 
 	c.alarmPub.Set("alarm-active", "false")
 
+	c.budget.endBurst(time.Now())
+	c.publishHornBudget()
+
 	c.active = false
 	return nil
 }
@@ -148,7 +349,11 @@ func (c *Controller) stopUnsafe() error {
 // runHornPattern runs the horn on/off pattern with integral cycles.
 // Each cycle is 800ms (400ms on + 400ms off). The pattern runs for
 // the number of complete cycles that fit within the given duration.
-func (c *Controller) runHornPattern(ctx context.Context, duration time.Duration) {
+// With honk unset the pattern only times the alarm; the horn stays off.
+// Every "on" is booked against the horn budget; once it is exhausted the
+// hazards carry on alone.
+func (c *Controller) runHornPattern(ctx context.Context, duration time.Duration, honk bool) {
+	const pulseDuration = 400 * time.Millisecond
 	const cycleDuration = 800 * time.Millisecond
 	const buffer = 200 * time.Millisecond
 	cycles := int((duration - buffer) / cycleDuration)
@@ -164,6 +369,7 @@ func (c *Controller) runHornPattern(ctx context.Context, duration time.Duration)
 
 	ticks := 0
 	totalTicks := cycles * 2 // 2 ticks per cycle (on + off)
+	budgetExhausted := false
 
 	for {
 		select {
@@ -172,9 +378,18 @@ func (c *Controller) runHornPattern(ctx context.Context, duration time.Duration)
 			return
 
 		case <-ticker.C:
-			if c.hornEnabled.Load() {
+			if honk && c.hornEnabled.Load() {
 				if ticks%2 == 0 {
-					_, _ = c.ipc.LPush("scooter:horn", "on")
+					now := time.Now()
+					if ok, reason := c.budget.allow(now, pulseDuration); ok {
+						c.budget.record(now, pulseDuration)
+						c.publishHornBudget()
+						_, _ = c.ipc.LPush("scooter:horn", "on")
+					} else if !budgetExhausted {
+						budgetExhausted = true
+						c.log.Warn("horn budget exhausted, continuing with hazards only", "limit", reason)
+						c.publishHornBudget()
+					}
 				} else {
 					_, _ = c.ipc.LPush("scooter:horn", "off")
 				}
@@ -192,45 +407,167 @@ func (c *Controller) runHornPattern(ctx context.Context, duration time.Duration)
 // BlinkHazards flashes the hazard lights 3 times as an L1 warning.
 // Each cycle: 600ms on (fade completes at 504ms) + 400ms off.
 // This function is non-blocking to avoid stalling the FSM event loop.
+// A no-op while an alarm is running, since the hazards are already on.
 func (c *Controller) BlinkHazards() error {
-	c.log.Info("blinking hazards")
+	return c.blink("L1 warning", 3, 600*time.Millisecond, 400*time.Millisecond)
+}
+
+// BlinkTipOver flashes the hazard lights 5 times, slower than the L1
+// warning (1.5s on + 1s off) so a knocked-over scooter can be told apart.
+// Same rules as BlinkHazards.
+func (c *Controller) BlinkTipOver() error {
+	return c.blink("tip-over", 5, 1500*time.Millisecond, time.Second)
+}
+
+// blink starts a hazard pattern of the given number of pulses.
+func (c *Controller) blink(pattern string, pulses int, on, off time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.active {
+		c.log.Debug("alarm active, hazards already on")
+		return nil
+	}
+
+	c.log.Info("blinking hazards", "pattern", pattern)
+
+	c.endBlinkUnsafe()
+	c.endLocateUnsafe()
+	c.takeBlinkerUnsafe()
 
 	if _, err := c.ipc.LPush("scooter:blinker", "both"); err != nil {
 		c.log.Error("failed to activate hazard lights", "error", err)
+		c.releaseBlinkerUnsafe()
 		return err
 	}
 
-	go func() {
-		for i := 0; i < 2; i++ {
-			time.Sleep(600 * time.Millisecond)
-			if _, err := c.ipc.LPush("scooter:blinker", "off"); err != nil {
-				c.log.Error("failed to deactivate hazard lights", "error", err)
-			}
-			time.Sleep(400 * time.Millisecond)
-			if _, err := c.ipc.LPush("scooter:blinker", "both"); err != nil {
-				c.log.Error("failed to activate hazard lights", "error", err)
-			}
+	ctx, cancel := context.WithCancel(c.ctx)
+	c.blinkCancel = cancel
+
+	go c.runBlinkPattern(ctx, pulses, on, off)
+
+	return nil
+}
+
+// runBlinkPattern finishes a blink pattern and hands the blinker back.
+// Cancelled when an alarm or another pattern takes over the outputs.
+func (c *Controller) runBlinkPattern(ctx context.Context, pulses int, on, off time.Duration) {
+	sleep := func(d time.Duration) bool {
+		select {
+		case <-time.After(d):
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	for i := 1; i < pulses; i++ {
+		if !sleep(on) {
+			return
 		}
-		time.Sleep(600 * time.Millisecond)
 		if _, err := c.ipc.LPush("scooter:blinker", "off"); err != nil {
 			c.log.Error("failed to deactivate hazard lights", "error", err)
 		}
-	}()
+		if !sleep(off) {
+			return
+		}
+		if _, err := c.ipc.LPush("scooter:blinker", "both"); err != nil {
+			c.log.Error("failed to activate hazard lights", "error", err)
+		}
+	}
+	if !sleep(on) {
+		return
+	}
 
-	return nil
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ctx.Err() != nil {
+		return
+	}
+	c.endBlinkUnsafe()
+	c.releaseBlinkerUnsafe()
+}
+
+// endBlinkUnsafe cancels a running hazard blink without locking (internal use).
+func (c *Controller) endBlinkUnsafe() {
+	if c.blinkCancel == nil {
+		return
+	}
+	c.blinkCancel()
+	c.blinkCancel = nil
+}
+
+// takeBlinkerUnsafe remembers what the rider left the blinker on (e.g.
+// hazards after a roadside breakdown) before the first alarm output takes
+// the blinker over. Nested takeovers keep the original state.
+func (c *Controller) takeBlinkerUnsafe() {
+	if c.blinkerOwned {
+		return
+	}
+	c.priorBlinker = c.readBlinkerState()
+	c.blinkerOwned = true
+	// Kept in the alarm hash so the next instance can hand it back if we die
+	// holding it.
+	c.alarmPub.Set("blinker-prior", c.priorBlinker)
+}
+
+// releaseBlinkerUnsafe hands the blinker back in the state it was taken in.
+func (c *Controller) releaseBlinkerUnsafe() {
+	if !c.blinkerOwned {
+		return
+	}
+	restore := c.priorBlinker
+	c.blinkerOwned = false
+	c.priorBlinker = ""
+	c.alarmPub.Set("blinker-prior", "")
+
+	if restore != "off" {
+		c.log.Info("restoring blinker state", "blinker", restore)
+	}
+	if _, err := c.ipc.LPush("scooter:blinker", restore); err != nil {
+		c.log.Error("failed to restore blinker state", "blinker", restore, "error", err)
+	}
+}
+
+// readBlinkerState returns the scooter:blinker command matching the current
+// vehicle blinker switch, "off" if unknown.
+func (c *Controller) readBlinkerState() string {
+	state, err := c.ipc.HGet("vehicle", "blinker:switch")
+	if err != nil {
+		if err != ipc.ErrNil {
+			c.log.Warn("failed to read blinker state", "error", err)
+		}
+		return "off"
+	}
+	switch state {
+	case "left", "right", "both":
+		return state
+	}
+	return "off"
 }
 
 // handleCommand handles a command string
 func (c *Controller) handleCommand(cmd string) {
 	switch cmd {
 	case "stop":
+		if c.commander != nil {
+			c.commander.ManualStop()
+			c.log.Info("manual stop requested")
+			return
+		}
 		c.Stop()
 		return
 	case "enable":
+		c.mu.Lock()
+		c.cancelReenableUnsafe()
+		c.mu.Unlock()
 		c.settingsPub.Set("alarm.enabled", "true")
 		c.log.Info("alarm enabled via command")
 		return
 	case "disable":
+		c.mu.Lock()
+		c.cancelReenableUnsafe()
+		c.mu.Unlock()
 		c.settingsPub.Set("alarm.enabled", "false")
 		c.log.Info("alarm disabled via command")
 		return
@@ -246,6 +583,53 @@ func (c *Controller) handleCommand(cmd string) {
 			c.log.Info("runtime disarm requested")
 		}
 		return
+	case "locate":
+		c.Locate(locateSourceCmd)
+		return
+	case "silence":
+		if c.commander != nil {
+			c.commander.Silence(0)
+			c.log.Info("silence requested")
+		}
+		return
+	}
+
+	if strings.HasPrefix(cmd, "disable-for:") {
+		var seconds int
+		if _, err := fmt.Sscanf(cmd, "disable-for:%d", &seconds); err != nil || seconds <= 0 {
+			c.log.Error("invalid disable-for command", "command", cmd, "error", err)
+			return
+		}
+		c.DisableUntil(time.Now().Add(time.Duration(seconds) * time.Second))
+		return
+	}
+
+	if strings.HasPrefix(cmd, "disable-until:") {
+		deadline, err := parseDeadline(strings.TrimPrefix(cmd, "disable-until:"))
+		if err != nil {
+			c.log.Error("invalid disable-until command", "command", cmd, "error", err)
+			return
+		}
+		c.DisableUntil(deadline)
+		return
+	}
+
+	if strings.HasPrefix(cmd, lostModeCmdPrefix) {
+		c.handleLostModeCommand(cmd)
+		return
+	}
+
+	if strings.HasPrefix(cmd, "silence:") {
+		var seconds int
+		if _, err := fmt.Sscanf(cmd, "silence:%d", &seconds); err != nil || seconds <= 0 {
+			c.log.Error("invalid silence command", "command", cmd, "error", err)
+			return
+		}
+		if c.commander != nil {
+			c.commander.Silence(seconds)
+			c.log.Info("silence requested", "seconds", seconds)
+		}
+		return
 	}
 
 	var duration int
@@ -255,5 +639,10 @@ func (c *Controller) handleCommand(cmd string) {
 		return
 	}
 
+	if c.commander != nil {
+		c.commander.ManualStart(duration)
+		c.log.Info("manual alarm requested", "duration", duration)
+		return
+	}
 	c.Start(time.Duration(duration) * time.Second)
 }
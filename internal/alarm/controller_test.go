@@ -37,6 +37,7 @@ func setupTestController(t *testing.T, hornEnabled bool) (*Controller, *ipc.Clie
 		ctx:         ctx,
 		log:         log,
 		active:      false,
+		budget:      newHornBudget(),
 	}
 	c.hornEnabled.Store(hornEnabled)
 
@@ -105,3 +106,71 @@ func TestController_HandleCommand_StartWithDuration(t *testing.T) {
 		t.Error("expected alarm to be inactive after stop")
 	}
 }
+
+func TestController_StopRestoresPriorHazards(t *testing.T) {
+	c, client := setupTestController(t, false)
+	defer client.Close()
+
+	client.HSet("vehicle", "blinker:switch", "both")
+	defer client.Raw().HDel(client.Context(), "vehicle", "blinker:switch")
+	client.Del("scooter:blinker")
+	defer client.Del("scooter:blinker")
+
+	c.Start(5 * time.Second)
+	c.Stop()
+
+	last, err := client.LPop("scooter:blinker")
+	if err != nil {
+		t.Fatalf("LPop failed: %v", err)
+	}
+	if last != "both" {
+		t.Errorf("expected blinker restored to 'both', got %q", last)
+	}
+}
+
+func TestController_ReconcileMidEpisode(t *testing.T) {
+	c, client := setupTestController(t, false)
+	defer client.Close()
+	defer client.HSet("alarm", "status", "")
+
+	// Died in the silent part of an L2 cycle, holding the rider's hazards.
+	client.HSet("alarm", "status", "level-2-triggered")
+	client.HSet("alarm", "alarm-active", "false")
+	client.HSet("alarm", "clean-shutdown", "false")
+	client.HSet("alarm", "blinker-prior", "both")
+
+	status, err := c.ReconcileStaleOutputs()
+	if err != nil {
+		t.Fatalf("ReconcileStaleOutputs failed: %v", err)
+	}
+	if status != "level-2-triggered" {
+		t.Errorf("expected previous status level-2-triggered, got %q", status)
+	}
+	if prior, _ := client.HGet("alarm", "blinker-prior"); prior != "" {
+		t.Errorf("expected blinker handed back, blinker-prior still %q", prior)
+	}
+	if c.blinkerOwned {
+		t.Error("expected blinker released")
+	}
+}
+
+func TestController_ReconcileCleanShutdownMidEpisode(t *testing.T) {
+	c, client := setupTestController(t, false)
+	defer client.Close()
+	defer client.HSet("alarm", "status", "")
+
+	client.HSet("alarm", "status", "level-2-triggered")
+	client.HSet("alarm", "alarm-active", "false")
+	client.HSet("alarm", "clean-shutdown", "true")
+
+	status, err := c.ReconcileStaleOutputs()
+	if err != nil {
+		t.Fatalf("ReconcileStaleOutputs failed: %v", err)
+	}
+	if status != "" {
+		t.Errorf("expected clean shutdown, got previous status %q", status)
+	}
+	if clean, _ := client.HGet("alarm", "clean-shutdown"); clean != "false" {
+		t.Errorf("expected clean-shutdown reset, got %q", clean)
+	}
+}
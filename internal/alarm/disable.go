@@ -0,0 +1,136 @@
+package alarm
+
+import (
+	"strconv"
+	"time"
+)
+
+// DisableUntil sets alarm.enabled=false and re-enables it at deadline. The
+// deadline is kept in the alarm hash (reenable-at, unix seconds) so a
+// restart in between still re-enables on time. An alarm the owner had
+// already switched off stays off: there is nothing to re-enable.
+func (c *Controller) DisableUntil(deadline time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !deadline.After(time.Now()) {
+		c.log.Warn("ignoring temporary disable with deadline in the past", "deadline", deadline)
+		return
+	}
+	if c.reenableTimer == nil {
+		if enabled, err := c.settingsPub.Get("alarm.enabled"); err == nil && enabled == "false" {
+			c.log.Info("alarm already disabled, not scheduling a re-enable", "deadline", deadline)
+			return
+		}
+	}
+
+	c.settingsPub.Set("alarm.enabled", "false")
+	c.alarmPub.Set("reenable-at", strconv.FormatInt(deadline.Unix(), 10))
+	c.scheduleReenableUnsafe(deadline)
+	c.log.Info("alarm disabled temporarily", "reenable_at", deadline)
+}
+
+// restoreReenable picks up a temporary disable from a previous instance,
+// re-enabling right away if the deadline passed while we were down.
+func (c *Controller) restoreReenable() {
+	value, err := c.alarmPub.Get("reenable-at")
+	if err != nil || value == "" {
+		return
+	}
+	ts, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		c.log.Warn("malformed reenable-at, clearing", "value", value)
+		c.alarmPub.Set("reenable-at", "")
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	deadline := time.Unix(ts, 0)
+	if enabled, err := c.settingsPub.Get("alarm.enabled"); err == nil && enabled == "true" {
+		c.log.Info("alarm enabled while stopped, dropping temporary disable", "reenable_at", deadline)
+		c.cancelReenableUnsafe()
+		return
+	}
+	if !deadline.After(time.Now()) {
+		c.log.Info("temporary disable expired while stopped, re-enabling alarm", "reenable_at", deadline)
+		c.reenableUnsafe()
+		return
+	}
+	c.log.Info("restoring temporary disable", "reenable_at", deadline)
+	c.scheduleReenableUnsafe(deadline)
+}
+
+// scheduleReenableUnsafe arms the re-enable timer without locking (internal use).
+func (c *Controller) scheduleReenableUnsafe(deadline time.Time) {
+	if c.reenableTimer != nil {
+		c.reenableTimer.Stop()
+	}
+	c.reenableGen++
+	gen := c.reenableGen
+	c.reenableTimer = time.AfterFunc(time.Until(deadline), func() {
+		c.reenableElapsed(gen)
+	})
+}
+
+// reenableElapsed re-enables the alarm when the timer of generation gen
+// fires. Stopping a timer doesn't stop a callback already waiting on the
+// mutex, so one that was replaced (a later deadline) or cancelled meanwhile
+// does nothing.
+func (c *Controller) reenableElapsed(gen uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen != c.reenableGen || c.reenableTimer == nil {
+		return
+	}
+	if enabled, err := c.settingsPub.Get("alarm.enabled"); err == nil && enabled == "true" {
+		c.log.Info("temporary disable elapsed, alarm already enabled")
+		c.cancelReenableUnsafe()
+		return
+	}
+	c.log.Info("temporary disable elapsed, re-enabling alarm")
+	c.reenableUnsafe()
+}
+
+// onEnabledChanged drops a pending re-enable once alarm.enabled turns true,
+// whichever client wrote it (a schedule rule, the settings hash directly),
+// so a stale deadline can't override a later change.
+func (c *Controller) onEnabledChanged(value string) error {
+	if value != "true" {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.reenableTimer == nil {
+		return nil
+	}
+	c.log.Info("alarm enabled during temporary disable, dropping re-enable")
+	c.cancelReenableUnsafe()
+	return nil
+}
+
+// reenableUnsafe sets alarm.enabled=true and drops the deadline (internal use).
+func (c *Controller) reenableUnsafe() {
+	c.settingsPub.Set("alarm.enabled", "true")
+	c.cancelReenableUnsafe()
+}
+
+// cancelReenableUnsafe drops a pending re-enable without touching
+// alarm.enabled, e.g. when the owner enables or disables explicitly.
+func (c *Controller) cancelReenableUnsafe() {
+	c.reenableGen++
+	if c.reenableTimer != nil {
+		c.reenableTimer.Stop()
+		c.reenableTimer = nil
+	}
+	c.alarmPub.Set("reenable-at", "")
+}
+
+// parseDeadline parses a disable-until timestamp: unix seconds or RFC 3339.
+func parseDeadline(s string) (time.Time, error) {
+	if ts, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(ts, 0), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
@@ -0,0 +1,113 @@
+package alarm
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestParseDeadline(t *testing.T) {
+	expected := time.Date(2026, 10, 18, 20, 0, 0, 0, time.UTC)
+
+	got, err := parseDeadline("1792353600")
+	if err != nil || !got.Equal(expected) {
+		t.Errorf("unix seconds: got %v, %v", got, err)
+	}
+
+	got, err = parseDeadline("2026-10-18T22:00:00+02:00")
+	if err != nil || !got.Equal(expected) {
+		t.Errorf("RFC 3339: got %v, %v", got, err)
+	}
+
+	if _, err := parseDeadline("tomorrow"); err == nil {
+		t.Error("expected error for malformed deadline")
+	}
+}
+
+func TestController_ReplacedReenableTimerIgnored(t *testing.T) {
+	c := &Controller{log: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+	c.scheduleReenableUnsafe(time.Now().Add(time.Hour))
+	stale := c.reenableGen
+	// A second disable-for extends the deadline while the first timer's
+	// callback is already waiting on the mutex.
+	c.scheduleReenableUnsafe(time.Now().Add(2 * time.Hour))
+	defer c.reenableTimer.Stop()
+
+	// Would dereference the nil settings publisher if it went ahead.
+	c.reenableElapsed(stale)
+	if c.reenableTimer == nil {
+		t.Error("expected the extended deadline to stay pending")
+	}
+}
+
+func TestController_DisableForSetsDeadline(t *testing.T) {
+	c, client := setupTestController(t, false)
+	defer client.Close()
+	defer client.HSet("alarm", "reenable-at", "")
+
+	c.handleCommand("disable-for:60")
+	defer func() {
+		c.mu.Lock()
+		c.cancelReenableUnsafe()
+		c.mu.Unlock()
+	}()
+
+	enabled, _ := client.HGet("settings", "alarm.enabled")
+	if enabled != "false" {
+		t.Errorf("expected alarm.enabled=false, got %q", enabled)
+	}
+	if c.reenableTimer == nil {
+		t.Error("expected re-enable timer to be armed")
+	}
+	if deadline, _ := client.HGet("alarm", "reenable-at"); deadline == "" {
+		t.Error("expected reenable-at to be published")
+	}
+}
+
+func TestController_EnableElsewhereDropsReenable(t *testing.T) {
+	c, client := setupTestController(t, false)
+	defer client.Close()
+	defer client.HSet("alarm", "reenable-at", "")
+
+	c.handleCommand("disable-for:60")
+	defer func() {
+		c.mu.Lock()
+		c.cancelReenableUnsafe()
+		c.mu.Unlock()
+	}()
+
+	// Our own disable write echoes back as false and must not cancel.
+	c.onEnabledChanged("false")
+	if c.reenableTimer == nil {
+		t.Fatal("expected re-enable timer to survive alarm.enabled=false")
+	}
+
+	c.onEnabledChanged("true")
+	if c.reenableTimer != nil {
+		t.Error("expected re-enable timer dropped once alarm.enabled=true")
+	}
+	if deadline, _ := client.HGet("alarm", "reenable-at"); deadline != "" {
+		t.Errorf("expected reenable-at cleared, got %q", deadline)
+	}
+}
+
+func TestController_DisableForWhenAlreadyDisabled(t *testing.T) {
+	c, client := setupTestController(t, false)
+	defer client.Close()
+	defer client.HSet("alarm", "reenable-at", "")
+
+	client.HSet("settings", "alarm.enabled", "false")
+	c.handleCommand("disable-for:60")
+
+	if c.reenableTimer != nil {
+		c.mu.Lock()
+		c.cancelReenableUnsafe()
+		c.mu.Unlock()
+		t.Error("expected no re-enable for an alarm that was already off")
+	}
+	if deadline, _ := client.HGet("alarm", "reenable-at"); deadline != "" {
+		t.Errorf("expected no reenable-at, got %q", deadline)
+	}
+}
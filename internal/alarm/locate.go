@@ -0,0 +1,171 @@
+package alarm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	ipc "github.com/librescoot/redis-ipc"
+)
+
+// RPC channel + method names served by alarm-service. Mirrors the
+// motion-service layout (one CallServer per service, dispatch by method).
+const (
+	alarmRPCChannel    = "alarm:rpc"
+	alarmMethodLocate  = "locate"
+	locateSourceCmd    = "command"
+	locateMinInterval  = 30 * time.Second
+	locateHazardPeriod = 3 * time.Second
+)
+
+// Reasons a locate request is refused. Returned verbatim in LocateResp.Reason.
+var (
+	errLocateRateLimited = errors.New("rate-limited")
+	errLocateUnlocked    = errors.New("vehicle-unlocked")
+	errLocateAlarmActive = errors.New("alarm-active")
+)
+
+// LocateReq is the wire payload for the locate RPC. Source names the caller
+// (e.g. "ble", "cloud") for the log only: it is self-declared, so it grants
+// nothing. Locate is unauthenticated and therefore limited to a locked
+// vehicle.
+type LocateReq struct {
+	Source string `json:"source"`
+}
+
+// LocateResp is what alarm-service answers a locate RPC with.
+type LocateResp struct {
+	Accepted bool   `json:"accepted"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// startRPCServer spins up a JSON-codec ipc client (the controller's own
+// client uses StringCodec, which can't carry typed payloads) and serves
+// the alarm:rpc methods on it.
+func (c *Controller) startRPCServer(redisAddr string) error {
+	rpc, err := ipc.New(
+		ipc.WithURL(redisAddr),
+		ipc.WithCodec(ipc.JSONCodec{}),
+	)
+	if err != nil {
+		return fmt.Errorf("create alarm rpc client: %w", err)
+	}
+	c.rpc = rpc
+	c.rpcServer = ipc.NewCallServer(rpc, alarmRPCChannel)
+	ipc.RegisterCall(c.rpcServer, alarmMethodLocate, func(req LocateReq) (LocateResp, error) {
+		if err := c.Locate(req.Source); err != nil {
+			return LocateResp{Accepted: false, Reason: err.Error()}, nil
+		}
+		return LocateResp{Accepted: true}, nil
+	})
+	c.rpcServer.Start()
+	return nil
+}
+
+// Locate runs the find-my-scooter chirp + hazard pattern. It is explicitly
+// not an alarm: alarm-active is left untouched. Anyone on the bus can ask,
+// so it is only allowed while the vehicle is locked, at most once per
+// locateMinInterval, and never on top of a running alarm.
+func (c *Controller) Locate(source string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.active {
+		c.log.Info("locate refused, alarm active", "source", source)
+		return errLocateAlarmActive
+	}
+
+	if since := time.Since(c.lastLocate); since < locateMinInterval {
+		c.log.Info("locate refused, rate limited", "source", source, "since_last", since)
+		return errLocateRateLimited
+	}
+
+	vehicleState, err := c.ipc.HGet("vehicle", "state")
+	if err != nil && err != ipc.ErrNil {
+		c.log.Warn("failed to read vehicle state for locate", "error", err)
+	}
+	if !isVehicleLocked(vehicleState) {
+		c.log.Info("locate refused, vehicle unlocked", "source", source, "vehicle_state", vehicleState)
+		return errLocateUnlocked
+	}
+
+	c.log.Info("locating scooter", "source", source)
+	c.lastLocate = time.Now()
+
+	c.endBlinkUnsafe()
+	c.takeBlinkerUnsafe()
+
+	ctx, cancel := context.WithCancel(c.ctx)
+	c.locateCancel = cancel
+	c.alarmPub.Set("locate-active", "true")
+
+	go c.runLocatePattern(ctx)
+
+	return nil
+}
+
+// isVehicleLocked reports whether the vehicle state means nobody unlocked it.
+func isVehicleLocked(state string) bool {
+	switch state {
+	case "stand-by", "shutting-down", "waiting-hibernation":
+		return true
+	}
+	return false
+}
+
+// runLocatePattern chirps the horn twice (150ms on/off) and holds the hazards
+// for locateHazardPeriod. Cancelled when an alarm takes over the outputs.
+func (c *Controller) runLocatePattern(ctx context.Context) {
+	if _, err := c.ipc.LPush("scooter:blinker", "both"); err != nil {
+		c.log.Error("failed to activate hazard lights", "error", err)
+	}
+
+	chirp := c.hornEnabled.Load()
+	for i := 0; i < 2 && chirp; i++ {
+		now := time.Now()
+		if ok, _ := c.budget.allow(now, 150*time.Millisecond); !ok {
+			break
+		}
+		c.budget.record(now, 150*time.Millisecond)
+		c.publishHornBudget()
+		c.ipc.LPush("scooter:horn", "on")
+		select {
+		case <-time.After(150 * time.Millisecond):
+		case <-ctx.Done():
+			c.ipc.LPush("scooter:horn", "off")
+			return
+		}
+		c.ipc.LPush("scooter:horn", "off")
+		select {
+		case <-time.After(150 * time.Millisecond):
+		case <-ctx.Done():
+			return
+		}
+	}
+
+	select {
+	case <-time.After(locateHazardPeriod):
+	case <-ctx.Done():
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ctx.Err() != nil {
+		return
+	}
+	c.endLocateUnsafe()
+	c.releaseBlinkerUnsafe()
+}
+
+// endLocateUnsafe cancels a running locate pattern without locking (internal use).
+// Leaves the blinker owned; whoever takes over releases it.
+func (c *Controller) endLocateUnsafe() {
+	if c.locateCancel == nil {
+		return
+	}
+	c.locateCancel()
+	c.locateCancel = nil
+	c.alarmPub.Set("locate-active", "false")
+}
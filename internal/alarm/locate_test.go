@@ -0,0 +1,50 @@
+package alarm
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsVehicleLocked(t *testing.T) {
+	tests := []struct {
+		state    string
+		expected bool
+	}{
+		{"stand-by", true},
+		{"waiting-hibernation", true},
+		{"shutting-down", true},
+		{"parked", false},
+		{"ready-to-drive", false},
+		{"waiting-seatbox", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := isVehicleLocked(tt.state); got != tt.expected {
+			t.Errorf("isVehicleLocked(%q) = %v, expected %v", tt.state, got, tt.expected)
+		}
+	}
+}
+
+func TestController_LocateRefusedWhileAlarmActive(t *testing.T) {
+	c, client := setupTestController(t, false)
+	defer client.Close()
+
+	c.Start(1 * time.Second)
+	defer c.Stop()
+
+	if err := c.Locate("ble"); err != errLocateAlarmActive {
+		t.Errorf("expected %v, got %v", errLocateAlarmActive, err)
+	}
+}
+
+func TestController_LocateRateLimited(t *testing.T) {
+	c, client := setupTestController(t, false)
+	defer client.Close()
+
+	c.lastLocate = time.Now()
+
+	if err := c.Locate("ble"); err != errLocateRateLimited {
+		t.Errorf("expected %v, got %v", errLocateRateLimited, err)
+	}
+}
@@ -0,0 +1,216 @@
+package alarm
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Lost mode commands are "lost-mode:<on|off>:<unix seconds>:<signature>",
+// where signature is the hex HMAC-SHA256 of "lost-mode:<on|off>:<unix
+// seconds>" keyed with the contents of the lost mode key file. Anyone who
+// can push onto scooter:alarm can silence or disarm, but only the owner's
+// backend, holding the key, can mark the scooter stolen or take that back.
+// The key lives in a file only alarm-service can read rather than in the
+// settings hash, which every service on the bus can read. Likewise the last
+// accepted command is kept in a state file only alarm-service can write, not
+// in the alarm hash: it says whether lost mode survives a restart and which
+// timestamps are replays, and is verified against the key again on read.
+const (
+	lostModeCmdPrefix = "lost-mode:"
+	// DefaultLostModeKeyFile is where the lost mode key is read from.
+	DefaultLostModeKeyFile = "/etc/librescoot/alarm/lost-mode.key"
+	// DefaultLostModeStateFile is where the last accepted command is kept.
+	DefaultLostModeStateFile = "/var/lib/librescoot/alarm/lost-mode.state"
+	// lostModeMaxSkew bounds how old (or early) a signed command may be.
+	lostModeMaxSkew = 5 * time.Minute
+)
+
+// Reasons a lost mode command is refused.
+var (
+	errLostModeNoKey     = errors.New("no lost-mode key configured")
+	errLostModeKeyPerm   = errors.New("lost-mode key file readable by group or others")
+	errLostModeStatePerm = errors.New("lost-mode state file writable by group or others")
+	errLostModeMalformed = errors.New("malformed command")
+	errLostModeSignature = errors.New("bad signature")
+	errLostModeStale     = errors.New("timestamp outside allowed skew")
+	errLostModeReplay    = errors.New("timestamp not newer than last accepted command")
+)
+
+// verifyLostModeCommand checks a lost mode command against key and returns
+// whether it enables lost mode and its timestamp. last is the timestamp of
+// the last accepted command; anything not newer is a replay.
+func verifyLostModeCommand(cmd, key string, now time.Time, last int64) (bool, int64, error) {
+	enabled, ts, err := parseLostModeCommand(cmd, key)
+	if err != nil {
+		return false, 0, err
+	}
+	if skew := now.Sub(time.Unix(ts, 0)); skew > lostModeMaxSkew || skew < -lostModeMaxSkew {
+		return false, 0, errLostModeStale
+	}
+	if ts <= last {
+		return false, 0, errLostModeReplay
+	}
+	return enabled, ts, nil
+}
+
+// parseLostModeCommand checks the signature of a lost mode command against
+// key and returns whether it enables lost mode and its timestamp.
+func parseLostModeCommand(cmd, key string) (bool, int64, error) {
+	if key == "" {
+		return false, 0, errLostModeNoKey
+	}
+	parts := strings.Split(strings.TrimPrefix(cmd, lostModeCmdPrefix), ":")
+	if len(parts) != 3 {
+		return false, 0, errLostModeMalformed
+	}
+	var enabled bool
+	switch parts[0] {
+	case "on":
+		enabled = true
+	case "off":
+	default:
+		return false, 0, errLostModeMalformed
+	}
+	ts, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return false, 0, errLostModeMalformed
+	}
+	signature, err := hex.DecodeString(parts[2])
+	if err != nil {
+		return false, 0, errLostModeMalformed
+	}
+
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(lostModeCmdPrefix + parts[0] + ":" + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return false, 0, errLostModeSignature
+	}
+	return enabled, ts, nil
+}
+
+// readLostModeKey reads the lost mode key from path. A missing file means no
+// key is configured; a file others can read is refused, as a leaked key
+// would let anyone mark the scooter stolen.
+func readLostModeKey(path string) (string, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", errLostModeNoKey
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to open lost-mode key: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to stat lost-mode key: %w", err)
+	}
+	if info.Mode().Perm()&0o077 != 0 {
+		return "", errLostModeKeyPerm
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return "", fmt.Errorf("failed to read lost-mode key: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// readLostModeState returns the lost mode state and timestamp of the last
+// accepted command kept at path, re-verified against key. A missing file
+// means no command was accepted yet; a file others can write, or one whose
+// signature doesn't check out, is refused.
+func readLostModeState(path, key string) (bool, int64, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, 0, nil
+	}
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to open lost-mode state: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to stat lost-mode state: %w", err)
+	}
+	if info.Mode().Perm()&0o022 != 0 {
+		return false, 0, errLostModeStatePerm
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to read lost-mode state: %w", err)
+	}
+	enabled, ts, err := parseLostModeCommand(strings.TrimSpace(string(data)), key)
+	if err != nil {
+		return false, 0, fmt.Errorf("lost-mode state: %w", err)
+	}
+	return enabled, ts, nil
+}
+
+// writeLostModeState replaces the state file at path with cmd, readable and
+// writable by alarm-service only.
+func writeLostModeState(path, cmd string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(cmd+"\n"), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// handleLostModeCommand authenticates a lost mode command, records it in the
+// state file and forwards it to the FSM. A command that can't be recorded is
+// refused: a restart would forget it and reopen the replay window.
+func (c *Controller) handleLostModeCommand(cmd string) {
+	key, err := readLostModeKey(c.lostModeKeyFile)
+	if err != nil {
+		c.log.Warn("refusing lost-mode command", "reason", err)
+		return
+	}
+	_, last, err := readLostModeState(c.lostModeStateFile, key)
+	if err != nil {
+		c.log.Warn("refusing lost-mode command", "reason", err)
+		return
+	}
+
+	enabled, _, err := verifyLostModeCommand(cmd, key, time.Now(), last)
+	if err != nil {
+		c.log.Warn("refusing lost-mode command", "reason", err)
+		return
+	}
+	if err := writeLostModeState(c.lostModeStateFile, cmd); err != nil {
+		c.log.Error("refusing lost-mode command, failed to record it", "error", err)
+		return
+	}
+
+	c.log.Info("lost-mode command accepted", "enabled", enabled)
+	if c.commander != nil {
+		c.commander.LostMode(enabled)
+	}
+}
+
+// LostModePersisted reports whether the last accepted lost mode command
+// enabled lost mode. The alarm hash isn't consulted: any client can write
+// it.
+func (c *Controller) LostModePersisted() (bool, error) {
+	key, err := readLostModeKey(c.lostModeKeyFile)
+	if errors.Is(err, errLostModeNoKey) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	enabled, _, err := readLostModeState(c.lostModeStateFile, key)
+	return enabled, err
+}
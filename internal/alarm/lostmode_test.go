@@ -0,0 +1,127 @@
+package alarm
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func signLostMode(key, action string, ts int64) string {
+	msg := "lost-mode:" + action + ":" + strconv.FormatInt(ts, 10)
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(msg))
+	return msg + ":" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyLostModeCommand(t *testing.T) {
+	now := time.Unix(1792353600, 0)
+	ts := now.Unix()
+
+	enabled, got, err := verifyLostModeCommand(signLostMode("secret", "on", ts), "secret", now, 0)
+	if err != nil || !enabled || got != ts {
+		t.Fatalf("expected valid enable, got %v %d %v", enabled, got, err)
+	}
+
+	enabled, _, err = verifyLostModeCommand(signLostMode("secret", "off", ts), "secret", now, 0)
+	if err != nil || enabled {
+		t.Fatalf("expected valid disable, got %v %v", enabled, err)
+	}
+
+	tests := []struct {
+		name string
+		cmd  string
+		key  string
+		last int64
+		want error
+	}{
+		{"no key", signLostMode("secret", "on", ts), "", 0, errLostModeNoKey},
+		{"wrong key", signLostMode("other", "on", ts), "secret", 0, errLostModeSignature},
+		{"unsigned", "lost-mode:on", "secret", 0, errLostModeMalformed},
+		{"bad action", signLostMode("secret", "maybe", ts), "secret", 0, errLostModeMalformed},
+		{"stale", signLostMode("secret", "on", ts-600), "secret", 0, errLostModeStale},
+		{"replay", signLostMode("secret", "on", ts), "secret", ts, errLostModeReplay},
+	}
+	for _, tt := range tests {
+		if _, _, err := verifyLostModeCommand(tt.cmd, tt.key, now, tt.last); !errors.Is(err, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, err)
+		}
+	}
+
+	// Flipping on to off must invalidate the signature.
+	forged := signLostMode("secret", "on", ts)
+	forged = "lost-mode:off" + forged[len("lost-mode:on"):]
+	if _, _, err := verifyLostModeCommand(forged, "secret", now, 0); !errors.Is(err, errLostModeSignature) {
+		t.Errorf("expected forged action to fail, got %v", err)
+	}
+}
+
+func TestReadLostModeKey(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "lost-mode.key")
+
+	if _, err := readLostModeKey(path); !errors.Is(err, errLostModeNoKey) {
+		t.Errorf("expected no key for a missing file, got %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	key, err := readLostModeKey(path)
+	if err != nil || key != "secret" {
+		t.Errorf("expected key %q, got %q %v", "secret", key, err)
+	}
+
+	if err := os.Chmod(path, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readLostModeKey(path); !errors.Is(err, errLostModeKeyPerm) {
+		t.Errorf("expected a world-readable key to be refused, got %v", err)
+	}
+}
+
+func TestLostModeState(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state", "lost-mode.state")
+
+	enabled, last, err := readLostModeState(path, "secret")
+	if err != nil || enabled || last != 0 {
+		t.Fatalf("expected no state for a missing file, got %v %d %v", enabled, last, err)
+	}
+
+	cmd := signLostMode("secret", "on", 1792353600)
+	if err := writeLostModeState(path, cmd); err != nil {
+		t.Fatal(err)
+	}
+	enabled, last, err = readLostModeState(path, "secret")
+	if err != nil || !enabled || last != 1792353600 {
+		t.Errorf("expected enabled at 1792353600, got %v %d %v", enabled, last, err)
+	}
+
+	if _, _, err := readLostModeState(path, "other"); !errors.Is(err, errLostModeSignature) {
+		t.Errorf("expected state signed with another key to be refused, got %v", err)
+	}
+
+	// A bus client rewriting the state without the key is caught.
+	if err := os.WriteFile(path, []byte("lost-mode:off:1792353700:00\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := readLostModeState(path, "secret"); !errors.Is(err, errLostModeSignature) {
+		t.Errorf("expected forged state to be refused, got %v", err)
+	}
+
+	if err := writeLostModeState(path, cmd); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, 0o666); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := readLostModeState(path, "secret"); !errors.Is(err, errLostModeStatePerm) {
+		t.Errorf("expected a world-writable state file to be refused, got %v", err)
+	}
+}
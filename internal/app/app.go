@@ -9,6 +9,7 @@ import (
 	"alarm-service/internal/fsm"
 	"alarm-service/internal/pm"
 	"alarm-service/internal/redis"
+	"alarm-service/internal/schedule"
 )
 
 // Config holds application configuration. The chip-config flags
@@ -32,6 +33,8 @@ type Config struct {
 	HairTriggerDurationFlagSet bool
 	L1Cooldown                 int
 	L1CooldownFlagSet          bool
+	LostModeKeyFile            string
+	LostModeStateFile          string
 }
 
 // App represents the alarm-service application.
@@ -45,6 +48,8 @@ type App struct {
 	inhibitor       *pm.Inhibitor
 	stateMachine    *fsm.StateMachine
 	subscriber      *redis.Subscriber
+	notifier        *redis.Notifier
+	scheduler       *schedule.Scheduler
 }
 
 // New creates a new App.
@@ -98,7 +103,46 @@ func (a *App) Run(ctx context.Context) error {
 		a.log,
 	)
 
+	a.notifier = redis.NewNotifier(a.redis, a.log)
+	a.notifier.Start()
+	defer a.notifier.Stop()
+	a.stateMachine.SetNotifier(a.notifier)
+	a.stateMachine.SetImmobilizer(a.publisher)
 	a.alarmController.SetCommander(a.stateMachine)
+	a.alarmController.SetLostModeKeyFile(a.cfg.LostModeKeyFile)
+	a.alarmController.SetLostModeStateFile(a.cfg.LostModeStateFile)
+
+	// Run before anything is queued: SendEvent drops events once the
+	// channel is full, and the initial sync alone queues dozens.
+	go a.stateMachine.Run(ctx)
+
+	// A previous instance that died mid-episode leaves its status (and, if
+	// mid-siren, alarm-active) behind and possibly the horn energized. Clean
+	// up before the FSM publishes anything, and let it decide whether the
+	// episode should resume.
+	if status, err := a.alarmController.ReconcileStaleOutputs(); err != nil {
+		a.log.Warn("reconcile stale alarm outputs failed", "error", err)
+	} else if status != "" {
+		a.log.Warn("recovered from unclean shutdown mid-episode", "previous_status", status)
+		a.stateMachine.SendEvent(fsm.UncleanShutdownEvent{Status: status})
+	}
+
+	// Lost mode survives restarts: the owner has to lift it explicitly. The
+	// state comes from the signed state file, never from the alarm hash.
+	if lost, err := a.alarmController.LostModePersisted(); err != nil {
+		a.log.Warn("read persisted lost mode failed", "error", err)
+	} else if lost {
+		a.log.Warn("restoring lost mode")
+		a.stateMachine.SendEvent(fsm.LostModeEvent{Enabled: true, Restored: true})
+	}
+
+	// A driving block is only lifted by an authorized disarm, restarts
+	// included.
+	if blocked, err := a.publisher.DriveBlocked(); err != nil {
+		a.log.Warn("read persisted driving block failed", "error", err)
+	} else if blocked {
+		a.stateMachine.SendEvent(fsm.DriveBlockRestoredEvent{})
+	}
 
 	a.subscriber = redis.NewSubscriber(a.redis, a.stateMachine, a.log)
 
@@ -123,7 +167,13 @@ func (a *App) Run(ctx context.Context) error {
 	}
 	defer a.subscriber.Stop()
 
-	go a.stateMachine.Run(ctx)
+	// A catch-up rule's arm/disarm is held back until the FSM has left
+	// init; sent earlier, the init state would swallow it.
+	a.scheduler = schedule.New(a.redis.IPC(), a.stateMachine, a.log)
+	if err := a.scheduler.Start(a.stateMachine.Ready()); err != nil {
+		return fmt.Errorf("start scheduler: %w", err)
+	}
+	defer a.scheduler.Stop()
 
 	<-ctx.Done()
 	a.log.Info("shutting down")
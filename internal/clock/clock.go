@@ -0,0 +1,43 @@
+// Package clock parses the daily time-of-day values used in settings:
+// quiet hours, schedule rules and the horn budget's night window.
+package clock
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Parse parses HH:MM into minutes since midnight.
+func Parse(s string) (int, error) {
+	var h, m int
+	if _, err := fmt.Sscanf(strings.TrimSpace(s), "%d:%d", &h, &m); err != nil {
+		return 0, fmt.Errorf("invalid time %q: %w", s, err)
+	}
+	if h < 0 || h > 23 || m < 0 || m > 59 {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	return h*60 + m, nil
+}
+
+// ParseRange parses HH:MM-HH:MM into start and end minutes since midnight.
+// End before start wraps past midnight (e.g. 22:00-07:00); an empty range
+// is an error.
+func ParseRange(s string) (int, int, error) {
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return 0, 0, errors.New("missing '-'")
+	}
+	start, err := Parse(from)
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := Parse(to)
+	if err != nil {
+		return 0, 0, err
+	}
+	if start == end {
+		return 0, 0, errors.New("empty range")
+	}
+	return start, end, nil
+}
@@ -0,0 +1,36 @@
+package clock
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+		ok   bool
+	}{
+		{"00:00", 0, true},
+		{"07:30", 7*60 + 30, true},
+		{" 23:59 ", 23*60 + 59, true},
+		{"24:00", 0, false},
+		{"12:60", 0, false},
+		{"noon", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("Parse(%q) = %d, %v; want %d, ok %v", tt.in, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	start, end, err := ParseRange("22:00-07:00")
+	if err != nil || start != 22*60 || end != 7*60 {
+		t.Errorf("ParseRange wrapped = %d, %d, %v", start, end, err)
+	}
+	for _, in := range []string{"22:00", "22:00-22:00", "22:00-25:00"} {
+		if _, _, err := ParseRange(in); err == nil {
+			t.Errorf("ParseRange(%q): expected error", in)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package fsm
+
+import (
+	"strings"
+	"time"
+)
+
+// ArmingFault is an arming precondition that currently fails. The value is
+// what gets published in arming-blocked.
+type ArmingFault string
+
+const (
+	ArmingFaultSeatboxOpen   ArmingFault = "seatbox-open"
+	ArmingFaultKickstandUp   ArmingFault = "kickstand-up"
+	ArmingFaultMotionService ArmingFault = "motion-service-unhealthy"
+)
+
+// armingFaultOrder fixes the order faults are published in.
+var armingFaultOrder = []ArmingFault{ArmingFaultSeatboxOpen, ArmingFaultKickstandUp, ArmingFaultMotionService}
+
+// ArmingAction is what delay_armed does when a precondition fails.
+type ArmingAction int
+
+const (
+	// ArmingActionWarn arms anyway and publishes the reason.
+	ArmingActionWarn ArmingAction = iota
+	// ArmingActionRefuse holds in delay_armed until the faults clear.
+	ArmingActionRefuse
+	// ArmingActionOff skips the checks.
+	ArmingActionOff
+)
+
+func (a ArmingAction) String() string {
+	switch a {
+	case ArmingActionRefuse:
+		return "refuse"
+	case ArmingActionOff:
+		return "off"
+	default:
+		return "warn"
+	}
+}
+
+// ParseArmingAction parses "off", "warn" or "refuse".
+func ParseArmingAction(s string) (ArmingAction, bool) {
+	switch s {
+	case "warn":
+		return ArmingActionWarn, true
+	case "refuse":
+		return ArmingActionRefuse, true
+	case "off":
+		return ArmingActionOff, true
+	}
+	return ArmingActionWarn, false
+}
+
+// armingBlockedReason lists the failing preconditions, comma-separated, or
+// "" when arming is clear or the checks are off.
+func (sm *StateMachine) armingBlockedReason() string {
+	if sm.armingAction == ArmingActionOff {
+		return ""
+	}
+	var reasons []string
+	for _, fault := range armingFaultOrder {
+		if sm.armingFaults[fault] {
+			reasons = append(reasons, string(fault))
+		}
+	}
+	return strings.Join(reasons, ",")
+}
+
+// setArmingFault records a precondition failing or clearing, and
+// re-evaluates a pending arming.
+func (sm *StateMachine) setArmingFault(fault ArmingFault, active bool) {
+	if sm.armingFaults[fault] == active {
+		return
+	}
+	sm.armingFaults[fault] = active
+	sm.log.Debug("arming precondition changed", "fault", fault, "active", active)
+	if sm.state == StateDelayArmed {
+		sm.checkArming(false)
+	}
+}
+
+// checkArming evaluates the preconditions in delay_armed and publishes the
+// outcome. It starts the arming delay on entry, or once a refused arming
+// clears, unless it has to refuse.
+func (sm *StateMachine) checkArming(entering bool) {
+	reason := sm.armingBlockedReason()
+	sm.publishArmingBlocked(reason)
+
+	if reason != "" && sm.armingAction == ArmingActionRefuse {
+		if !sm.armingRefused {
+			sm.log.Warn("arming refused, preconditions failed", "reason", reason)
+		}
+		sm.armingRefused = true
+		sm.stopTimer("delay_armed")
+		// Don't keep the scooter awake while the rider walks off with
+		// the seatbox open.
+		sm.inhibitor.Release()
+		return
+	}
+	if reason != "" {
+		sm.log.Warn("arming despite failed preconditions", "reason", reason)
+	}
+	if !entering && !sm.armingRefused {
+		return
+	}
+
+	if sm.armingRefused {
+		sm.log.Info("arming preconditions met, arming")
+		sm.armingRefused = false
+		if err := sm.inhibitor.Acquire("Arming alarm"); err != nil {
+			sm.log.Error("failed to acquire inhibitor", "error", err)
+		}
+	}
+	sm.startTimer("delay_armed", 5*time.Second, func() {
+		sm.SendEvent(DelayArmedTimerEvent{})
+	})
+}
+
+// publishArmingBlocked publishes the failing preconditions and the action
+// taken on them, if they changed.
+func (sm *StateMachine) publishArmingBlocked(reason string) {
+	if reason == sm.armingBlocked {
+		return
+	}
+	sm.armingBlocked = reason
+	action := ""
+	if reason != "" {
+		action = sm.armingAction.String()
+	}
+	sm.publishFields(map[string]string{
+		"arming-blocked":        reason,
+		"arming-blocked-action": action,
+	})
+}
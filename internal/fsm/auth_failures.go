@@ -0,0 +1,79 @@
+package fsm
+
+import (
+	"strconv"
+	"time"
+)
+
+// Auth failure monitor defaults: five failed keycard/BLE unlocks within a
+// minute is well past a fumbling owner.
+const (
+	defaultAuthFailureThreshold = 5
+	defaultAuthFailureWindow    = 60 // seconds
+)
+
+// onAuthFailure counts a failed unlock attempt if the alarm is protecting
+// the scooter, and reports whether it counted.
+func (sm *StateMachine) onAuthFailure(source string) bool {
+	if !sm.countsAuthFailures() {
+		sm.log.Debug("ignoring auth failure while not armed", "source", source, "state", sm.state.String())
+		return false
+	}
+	sm.recordAuthFailure(source)
+	return true
+}
+
+// recordAuthFailure counts a failed unlock attempt and escalates once the
+// threshold is reached within the window.
+func (sm *StateMachine) recordAuthFailure(source string) {
+	now := time.Now()
+	sm.pruneAuthFailures(now)
+	sm.authFailures = append(sm.authFailures, now)
+	count := len(sm.authFailures)
+
+	sm.log.Warn("authentication failure", "source", source, "count", count, "threshold", sm.authFailureThreshold)
+	sm.publishFields(map[string]string{
+		"auth-failures":        strconv.Itoa(count),
+		"auth-failures-source": source,
+		"auth-failures-last":   strconv.FormatInt(now.Unix(), 10),
+	})
+	sm.authFailuresPublished = true
+
+	if sm.authFailureLevel == TamperLevelOff || sm.authFailureThreshold <= 0 || count < sm.authFailureThreshold {
+		return
+	}
+	// Start counting afresh so escalation needs another full run of failures.
+	sm.authFailures = nil
+	sm.SendEvent(AuthFailureThresholdEvent{Count: count, Level: sm.authFailureLevel})
+}
+
+// countsAuthFailures reports whether a failed unlock counts: only while
+// armed, in an episode or lost. A disarmed scooter is the owner's to fumble
+// with, and those failures mustn't carry into the next armed period.
+func (sm *StateMachine) countsAuthFailures() bool {
+	return sm.state == StateArmed || sm.lostMode || isEpisodeState(sm.state)
+}
+
+// pruneAuthFailures drops failures that fell out of the window.
+func (sm *StateMachine) pruneAuthFailures(now time.Time) {
+	cutoff := now.Add(-time.Duration(sm.authFailureWindow) * time.Second)
+	keep := sm.authFailures[:0]
+	for _, t := range sm.authFailures {
+		if t.After(cutoff) {
+			keep = append(keep, t)
+		}
+	}
+	sm.authFailures = keep
+}
+
+// clearAuthFailures resets the counter once the owner is back.
+func (sm *StateMachine) clearAuthFailures() {
+	sm.authFailures = nil
+	if !sm.authFailuresPublished {
+		return
+	}
+	sm.authFailuresPublished = false
+	if err := sm.publisher.PublishField("auth-failures", "0"); err != nil {
+		sm.log.Error("failed to publish auth failures", "error", err)
+	}
+}
@@ -1,5 +1,7 @@
 package fsm
 
+import "time"
+
 // Event represents an event that can trigger state transitions
 type Event interface {
 	Type() string
@@ -24,6 +26,284 @@ type HornSettingChangedEvent struct {
 
 func (e HornSettingChangedEvent) Type() string { return "horn_setting_changed" }
 
+// HornBudgetChangedEvent signals a horn duty-cycle limit setting changed.
+// Values are in seconds; zero leaves that limit unchanged.
+type HornBudgetChangedEvent struct {
+	MaxPerHour  int
+	MaxPerNight int
+	MinRest     int
+}
+
+func (e HornBudgetChangedEvent) Type() string { return "horn_budget_changed" }
+
+// HornNightChangedEvent signals the horn budget night window changed.
+// Start and End are minutes since midnight.
+type HornNightChangedEvent struct {
+	Start int
+	End   int
+}
+
+func (e HornNightChangedEvent) Type() string { return "horn_night_changed" }
+
+// WheelTamperEvent signals the rear wheel turned or the ECU reported speed
+// for longer than the wheel tamper minimum duration
+type WheelTamperEvent struct {
+	Speed int
+	RPM   int
+}
+
+func (e WheelTamperEvent) Type() string { return "wheel_tamper" }
+
+// InputTamperEvent signals a vehicle input (kickstand, handlebar lock,
+// brakes) changed while locked, with the level it is configured to escalate to
+type InputTamperEvent struct {
+	Input TamperInput
+	Level TamperLevel
+}
+
+func (e InputTamperEvent) Type() string { return "input_tamper" }
+
+// BatteryRemovedEvent signals a battery was removed or disconnected. Battery
+// is the hash it was seen on: battery:0, battery:1, cb-battery or aux-battery.
+type BatteryRemovedEvent struct {
+	Battery string
+}
+
+func (e BatteryRemovedEvent) Type() string { return "battery_removed" }
+
+// PresenceChangedEvent signals a trusted BLE device connected (Mode set) or
+// disconnected (Mode PresenceNone)
+type PresenceChangedEvent struct {
+	Device string
+	Mode   PresenceMode
+}
+
+func (e PresenceChangedEvent) Type() string { return "presence_changed" }
+
+// TowDetectedEvent signals a sustained motion episode: the scooter is being
+// lifted or towed rather than bumped
+type TowDetectedEvent struct {
+	Events int    // motion events counted, 0 when motion-service reported it
+	Source string // TowSourceEventCount or TowSourceMotionService
+}
+
+func (e TowDetectedEvent) Type() string { return "tow_detected" }
+
+// TowDetectedEvent sources.
+const (
+	TowSourceEventCount    = "event-count"
+	TowSourceMotionService = "motion-service"
+)
+
+// LostModeEvent signals an authenticated command entering or leaving lost
+// mode. Restored marks lost mode carried over from before a restart.
+type LostModeEvent struct {
+	Enabled  bool
+	Restored bool
+}
+
+func (e LostModeEvent) Type() string { return "lost_mode" }
+
+// LostModeIntervalChangedEvent signals alarm.lost-mode-interval changed (seconds)
+type LostModeIntervalChangedEvent struct {
+	Interval int
+}
+
+func (e LostModeIntervalChangedEvent) Type() string { return "lost_mode_interval_changed" }
+
+// LostModeBeaconChangedEvent signals alarm.lost-mode-beacon changed (seconds)
+type LostModeBeaconChangedEvent struct {
+	Interval int
+}
+
+func (e LostModeBeaconChangedEvent) Type() string { return "lost_mode_beacon_changed" }
+
+// LostModeBeaconTimerEvent signals the next lost mode hazard beacon is due
+type LostModeBeaconTimerEvent struct{}
+
+func (e LostModeBeaconTimerEvent) Type() string { return "lost_mode_beacon_timer" }
+
+// ImmobilizerChangedEvent signals alarm.immobilizer changed
+type ImmobilizerChangedEvent struct {
+	Enabled bool
+}
+
+func (e ImmobilizerChangedEvent) Type() string { return "immobilizer_changed" }
+
+// DriveBlockRestoredEvent signals the previous instance left driving blocked
+type DriveBlockRestoredEvent struct{}
+
+func (e DriveBlockRestoredEvent) Type() string { return "drive_block_restored" }
+
+// RiderActivityEvent signals someone handling the scooter while it is
+// parked (brakes, kickstand, blinker switch, keycard, ...)
+type RiderActivityEvent struct {
+	Source string
+}
+
+func (e RiderActivityEvent) Type() string { return "rider_activity" }
+
+// UnattendedTimerEvent signals the parked scooter saw no rider activity for
+// the unattended timeout
+type UnattendedTimerEvent struct{}
+
+func (e UnattendedTimerEvent) Type() string { return "unattended_timer" }
+
+// UnattendedTimeoutChangedEvent signals alarm.unattended-timeout changed (seconds)
+type UnattendedTimeoutChangedEvent struct {
+	Timeout int
+}
+
+func (e UnattendedTimeoutChangedEvent) Type() string { return "unattended_timeout_changed" }
+
+// UnattendedHazardsChangedEvent signals alarm.unattended-hazards changed
+type UnattendedHazardsChangedEvent struct {
+	Enabled bool
+}
+
+func (e UnattendedHazardsChangedEvent) Type() string { return "unattended_hazards_changed" }
+
+// ArmingFaultEvent signals an arming precondition starting (Active) or
+// ceasing to fail
+type ArmingFaultEvent struct {
+	Fault  ArmingFault
+	Active bool
+}
+
+func (e ArmingFaultEvent) Type() string { return "arming_fault" }
+
+// ArmingActionChangedEvent signals alarm.arming-preconditions changed
+type ArmingActionChangedEvent struct {
+	Action ArmingAction
+}
+
+func (e ArmingActionChangedEvent) Type() string { return "arming_action_changed" }
+
+// TipOverEvent signals motion-service reporting the scooter on its side
+// (TippedOver) or upright again. Hazards asks for the tip-over blink.
+type TipOverEvent struct {
+	TippedOver bool
+	Hazards    bool
+}
+
+func (e TipOverEvent) Type() string { return "tip_over" }
+
+// KeycardAuthorizedEvent signals an authorized keycard tap
+type KeycardAuthorizedEvent struct {
+	UID string
+}
+
+func (e KeycardAuthorizedEvent) Type() string { return "keycard_authorized" }
+
+// AuthFailureEvent signals a failed keycard or BLE unlock attempt
+type AuthFailureEvent struct {
+	Source string // "keycard" or "ble"
+}
+
+func (e AuthFailureEvent) Type() string { return "auth_failure" }
+
+// AuthFailureThresholdEvent signals the auth failure threshold was reached
+// within the window, with the level it is configured to escalate to
+type AuthFailureThresholdEvent struct {
+	Count int
+	Level TamperLevel
+}
+
+func (e AuthFailureThresholdEvent) Type() string { return "auth_failure_threshold" }
+
+// AuthFailureThresholdChangedEvent signals alarm.auth-failure-threshold changed
+type AuthFailureThresholdChangedEvent struct {
+	Threshold int
+}
+
+func (e AuthFailureThresholdChangedEvent) Type() string { return "auth_failure_threshold_changed" }
+
+// AuthFailureWindowChangedEvent signals alarm.auth-failure-window changed (seconds)
+type AuthFailureWindowChangedEvent struct {
+	Window int
+}
+
+func (e AuthFailureWindowChangedEvent) Type() string { return "auth_failure_window_changed" }
+
+// AuthFailureLevelChangedEvent signals alarm.auth-failure-level changed
+type AuthFailureLevelChangedEvent struct {
+	Level TamperLevel
+}
+
+func (e AuthFailureLevelChangedEvent) Type() string { return "auth_failure_level_changed" }
+
+// GPSUpdateEvent carries the latest gps hash snapshot
+type GPSUpdateEvent struct {
+	Position Position
+}
+
+func (e GPSUpdateEvent) Type() string { return "gps_update" }
+
+// GeofenceRadiusChangedEvent signals alarm.geofence-radius changed (metres,
+// 0 disables the geofence)
+type GeofenceRadiusChangedEvent struct {
+	Radius int
+}
+
+func (e GeofenceRadiusChangedEvent) Type() string { return "geofence_radius_changed" }
+
+// TrackingIntervalChangedEvent signals alarm.tracking-interval changed (seconds)
+type TrackingIntervalChangedEvent struct {
+	Interval int
+}
+
+func (e TrackingIntervalChangedEvent) Type() string { return "tracking_interval_changed" }
+
+// GeofenceBreachEvent signals the scooter moved beyond the geofence radius
+// from where it was armed
+type GeofenceBreachEvent struct {
+	Distance float64
+}
+
+func (e GeofenceBreachEvent) Type() string { return "geofence_breach" }
+
+// TrackingTimerEvent signals it's time to publish the next tracking position
+type TrackingTimerEvent struct{}
+
+func (e TrackingTimerEvent) Type() string { return "tracking_timer" }
+
+// SilentModeChangedEvent signals alarm.mode switched between normal and
+// silent. In silent mode detection runs as usual but horn and hazards are
+// suppressed in favour of notifications.
+type SilentModeChangedEvent struct {
+	Silent bool
+}
+
+func (e SilentModeChangedEvent) Type() string { return "silent_mode_changed" }
+
+// QuietHoursChangedEvent signals the quiet hours ranges changed. Empty
+// Ranges disables quiet hours.
+type QuietHoursChangedEvent struct {
+	Ranges []QuietRange
+}
+
+func (e QuietHoursChangedEvent) Type() string { return "quiet_hours_changed" }
+
+// QuietHoursTimezoneChangedEvent signals the timezone quiet hours are
+// evaluated in changed
+type QuietHoursTimezoneChangedEvent struct {
+	Location *time.Location
+}
+
+func (e QuietHoursTimezoneChangedEvent) Type() string { return "quiet_hours_timezone_changed" }
+
+// QuietHoursModeChangedEvent signals the quiet hours alarm mode changed
+type QuietHoursModeChangedEvent struct {
+	Mode QuietMode
+}
+
+func (e QuietHoursModeChangedEvent) Type() string { return "quiet_hours_mode_changed" }
+
+// QuietHoursBoundaryTimerEvent signals a quiet hours range started or ended
+type QuietHoursBoundaryTimerEvent struct{}
+
+func (e QuietHoursBoundaryTimerEvent) Type() string { return "quiet_hours_boundary_timer" }
+
 // AlarmDurationChangedEvent signals alarm duration changed
 type AlarmDurationChangedEvent struct {
 	Duration int
@@ -119,13 +399,46 @@ type HibernationImminentEvent struct {
 
 func (e HibernationImminentEvent) Type() string { return "hibernation_imminent" }
 
-// ManualTriggerEvent signals manual alarm trigger
+// UncleanShutdownEvent signals that the previous alarm-service instance died
+// with an alarm output running. Status is the alarm status it last published.
+type UncleanShutdownEvent struct {
+	Status string
+}
+
+func (e UncleanShutdownEvent) Type() string { return "unclean_shutdown" }
+
+// ManualTriggerEvent signals manual alarm trigger. Duration is in seconds;
+// zero uses the configured alarm duration.
 type ManualTriggerEvent struct {
 	Duration int
 }
 
 func (e ManualTriggerEvent) Type() string { return "manual_trigger" }
 
+// ManualStopEvent signals a manual stop command
+type ManualStopEvent struct{}
+
+func (e ManualStopEvent) Type() string { return "manual_stop" }
+
+// ManualAlarmTimerEvent signals the manual alarm duration has elapsed
+type ManualAlarmTimerEvent struct{}
+
+func (e ManualAlarmTimerEvent) Type() string { return "manual_alarm_timer" }
+
+// SilenceEvent ends the audible outputs of the current episode and mutes the
+// horn for a snooze window, without disarming. Duration is in seconds; zero
+// selects the default snooze window.
+type SilenceEvent struct {
+	Duration int
+}
+
+func (e SilenceEvent) Type() string { return "silence" }
+
+// SilenceExpiredTimerEvent signals the snooze window has elapsed
+type SilenceExpiredTimerEvent struct{}
+
+func (e SilenceExpiredTimerEvent) Type() string { return "silence_expired_timer" }
+
 // SeatboxOpenedEvent signals authorized seatbox opening
 type SeatboxOpenedEvent struct{}
 
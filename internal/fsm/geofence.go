@@ -0,0 +1,99 @@
+package fsm
+
+import (
+	"strconv"
+	"time"
+)
+
+// Geofence defaults. GPS jitter on a parked scooter stays well inside
+// defaultGeofenceRadius; anything beyond it with a good fix means the
+// scooter was carried or towed away.
+const (
+	defaultGeofenceRadius   = 100 // metres, 0 disables the geofence
+	defaultTrackingInterval = 30  // seconds between tracking updates
+)
+
+// checkGeofence escalates if the scooter has left the armed position by more
+// than the geofence radius. Motion sensors can be defeated by lifting the
+// scooter gently; GPS displacement can't.
+func (sm *StateMachine) checkGeofence() {
+	if sm.geofenceRadius <= 0 || sm.tracking {
+		return
+	}
+	switch sm.state {
+	case StateArmed, StateTriggerLevel1Wait, StateTriggerLevel1, StateTriggerLevel2, StateWaitingMovement:
+	default:
+		return
+	}
+	distance, ok := sm.distanceFromArmed()
+	if !ok || distance <= float64(sm.geofenceRadius) {
+		return
+	}
+
+	sm.log.Warn("scooter left geofence", "distance", distance, "radius", sm.geofenceRadius)
+	sm.startTracking()
+	sm.SendEvent(GeofenceBreachEvent{Distance: distance})
+}
+
+// onGeofenceBreach tells the owner the scooter left the geofence. The
+// breach then escalates armed and L1 states to L2; an episode already in
+// L2 keeps running with tracking on.
+func (sm *StateMachine) onGeofenceBreach(distance float64) {
+	sm.log.Warn("geofence breach", "distance", distance, "state", sm.state.String())
+	sm.notify(NotificationGeofenceBreach)
+}
+
+// startTracking publishes the position every tracking interval until the
+// scooter is disarmed.
+func (sm *StateMachine) startTracking() {
+	sm.tracking = true
+	if err := sm.publisher.PublishField("tracking", "true"); err != nil {
+		sm.log.Error("failed to publish tracking", "error", err)
+	}
+	sm.publishTrackingPosition()
+	sm.scheduleTrackingTimer()
+}
+
+// trackingElapsed publishes the next tracking update.
+func (sm *StateMachine) trackingElapsed() {
+	if !sm.tracking {
+		return
+	}
+	sm.publishTrackingPosition()
+	sm.scheduleTrackingTimer()
+}
+
+// stopTracking ends tracking mode.
+func (sm *StateMachine) stopTracking() {
+	if !sm.tracking {
+		return
+	}
+	sm.log.Info("tracking stopped")
+	sm.tracking = false
+	sm.stopTimer("tracking")
+	if err := sm.publisher.PublishField("tracking", "false"); err != nil {
+		sm.log.Error("failed to publish tracking", "error", err)
+	}
+}
+
+func (sm *StateMachine) scheduleTrackingTimer() {
+	interval := sm.trackingInterval
+	if sm.lostMode {
+		interval = sm.lostModeInterval
+	}
+	sm.startTimer("tracking", time.Duration(interval)*time.Second, func() {
+		sm.SendEvent(TrackingTimerEvent{})
+	})
+}
+
+// publishTrackingPosition publishes the current position to the alarm hash
+// and as a tracking notification.
+func (sm *StateMachine) publishTrackingPosition() {
+	fields := positionFields("tracking", sm.position)
+	fields["tracking-distance"] = ""
+	if d, ok := sm.distanceFromArmed(); ok {
+		fields["tracking-distance"] = strconv.FormatFloat(d, 'f', 0, 64)
+	}
+	sm.publishFields(fields)
+	sm.notify(NotificationTracking)
+}
@@ -0,0 +1,131 @@
+package fsm
+
+import (
+	"math"
+	"strconv"
+	"time"
+)
+
+// earthRadius is the mean Earth radius in metres, for haversine distances.
+const earthRadius = 6371000.0
+
+// Position is a snapshot of the gps hash.
+type Position struct {
+	Latitude  float64
+	Longitude float64
+	Fix       string // "none", "2d", "3d"
+	Time      time.Time
+}
+
+// ParsePosition builds a Position from the gps hash fields latitude,
+// longitude, fix and timestamp (RFC3339 or unix seconds). Missing or
+// malformed fields are left zero.
+func ParsePosition(fields map[string]string) Position {
+	p := Position{Fix: fields["fix"]}
+	p.Latitude, _ = strconv.ParseFloat(fields["latitude"], 64)
+	p.Longitude, _ = strconv.ParseFloat(fields["longitude"], 64)
+	if ts := fields["timestamp"]; ts != "" {
+		if t, err := time.Parse(time.RFC3339, ts); err == nil {
+			p.Time = t
+		} else if unix, err := strconv.ParseInt(ts, 10, 64); err == nil {
+			p.Time = time.Unix(unix, 0)
+		}
+	}
+	return p
+}
+
+// Valid reports whether the position comes from a 2D or 3D fix.
+func (p Position) Valid() bool {
+	return (p.Fix == "2d" || p.Fix == "3d") && (p.Latitude != 0 || p.Longitude != 0)
+}
+
+// distanceMeters returns the great-circle distance between two positions.
+func distanceMeters(a, b Position) float64 {
+	lat1 := a.Latitude * math.Pi / 180
+	lat2 := b.Latitude * math.Pi / 180
+	dLat := lat2 - lat1
+	dLon := (b.Longitude - a.Longitude) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
+
+// positionFields renders a position as alarm hash fields under prefix.
+// An invalid position clears the fields.
+func positionFields(prefix string, p Position) map[string]string {
+	fields := map[string]string{
+		prefix + "-latitude":  "",
+		prefix + "-longitude": "",
+		prefix + "-fix":       p.Fix,
+		prefix + "-gps-time":  "",
+	}
+	if p.Valid() {
+		fields[prefix+"-latitude"] = strconv.FormatFloat(p.Latitude, 'f', 6, 64)
+		fields[prefix+"-longitude"] = strconv.FormatFloat(p.Longitude, 'f', 6, 64)
+	}
+	if !p.Time.IsZero() {
+		fields[prefix+"-gps-time"] = strconv.FormatInt(p.Time.Unix(), 10)
+	}
+	return fields
+}
+
+// updatePosition caches a new GPS fix, takes it as the armed position if
+// none was taken yet, and checks the geofence.
+func (sm *StateMachine) updatePosition(p Position) {
+	sm.position = p
+	if sm.state == StateArmed {
+		sm.captureArmedPosition()
+	}
+	sm.checkGeofence()
+}
+
+// distanceFromArmed returns how far the current position is from where the
+// scooter was armed, and whether both fixes were good enough to tell.
+func (sm *StateMachine) distanceFromArmed() (float64, bool) {
+	if !sm.position.Valid() || !sm.armedPosition.Valid() {
+		return 0, false
+	}
+	return distanceMeters(sm.armedPosition, sm.position), true
+}
+
+// captureArmedPosition records the current position as the reference for
+// this lock, unless one has already been taken.
+func (sm *StateMachine) captureArmedPosition() {
+	if sm.armedPosition.Valid() || !sm.position.Valid() {
+		return
+	}
+	sm.armedPosition = sm.position
+	sm.log.Info("armed position captured",
+		"latitude", sm.position.Latitude, "longitude", sm.position.Longitude, "fix", sm.position.Fix)
+	sm.publishFields(positionFields("armed", sm.armedPosition))
+}
+
+// clearArmedPosition forgets the reference position once the scooter is
+// disarmed or disabled.
+func (sm *StateMachine) clearArmedPosition() {
+	if sm.armedPosition == (Position{}) {
+		return
+	}
+	sm.armedPosition = Position{}
+	sm.publishFields(positionFields("armed", sm.armedPosition))
+}
+
+// captureTriggerPosition publishes where the scooter was when an L1/L2
+// trigger fired and how far that is from the armed position.
+func (sm *StateMachine) captureTriggerPosition() {
+	fields := positionFields("trigger", sm.position)
+	fields["trigger-distance"] = ""
+	if d, ok := sm.distanceFromArmed(); ok {
+		fields["trigger-distance"] = strconv.FormatFloat(d, 'f', 0, 64)
+	}
+	sm.publishFields(fields)
+}
+
+// publishFields publishes auxiliary alarm hash fields in one update, so
+// watchers never see a half-written position.
+func (sm *StateMachine) publishFields(fields map[string]string) {
+	if err := sm.publisher.PublishFields(fields); err != nil {
+		sm.log.Error("failed to publish fields", "error", err)
+	}
+}
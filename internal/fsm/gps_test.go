@@ -0,0 +1,46 @@
+package fsm
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestParsePosition(t *testing.T) {
+	p := ParsePosition(map[string]string{
+		"latitude":  "52.520008",
+		"longitude": "13.404954",
+		"fix":       "3d",
+		"timestamp": "2026-10-18T12:00:00Z",
+	})
+	if !p.Valid() {
+		t.Fatal("expected valid position")
+	}
+	if p.Latitude != 52.520008 || p.Longitude != 13.404954 {
+		t.Errorf("unexpected coordinates %v,%v", p.Latitude, p.Longitude)
+	}
+	if want := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC); !p.Time.Equal(want) {
+		t.Errorf("expected time %v, got %v", want, p.Time)
+	}
+
+	p = ParsePosition(map[string]string{"timestamp": "1760000000", "fix": "none"})
+	if p.Valid() {
+		t.Error("expected position without fix to be invalid")
+	}
+	if p.Time.Unix() != 1760000000 {
+		t.Errorf("expected unix timestamp, got %v", p.Time)
+	}
+}
+
+func TestDistanceMeters(t *testing.T) {
+	a := Position{Latitude: 52.520008, Longitude: 13.404954, Fix: "3d"}
+	b := Position{Latitude: 52.521008, Longitude: 13.404954, Fix: "3d"}
+
+	// 0.001° of latitude is about 111 m.
+	if d := distanceMeters(a, b); math.Abs(d-111.2) > 1 {
+		t.Errorf("expected ~111 m, got %.1f", d)
+	}
+	if d := distanceMeters(a, a); d != 0 {
+		t.Errorf("expected 0 m, got %.1f", d)
+	}
+}
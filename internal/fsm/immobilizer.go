@@ -0,0 +1,100 @@
+package fsm
+
+import (
+	"strconv"
+	"time"
+)
+
+// Immobilizer asks vehicle-service to refuse driving. vehicle-service
+// applies a block on the next unlock; it never cuts power mid-ride.
+type Immobilizer interface {
+	BlockDrive(blocked bool) error
+}
+
+// SetImmobilizer sets the Immobilizer used during L2 and lost mode.
+func (sm *StateMachine) SetImmobilizer(i Immobilizer) {
+	sm.immobilizer = i
+}
+
+// blockDrive blocks driving for the running episode, if the immobilizer is
+// enabled. The block outlives the episode: a thief who unlocks the scooter
+// once the siren gave up still can't ride off.
+func (sm *StateMachine) blockDrive() {
+	if !sm.immobilizerEnabled || sm.driveBlocked || sm.immobilizer == nil {
+		return
+	}
+	if err := sm.immobilizer.BlockDrive(true); err != nil {
+		sm.log.Error("failed to block driving", "error", err)
+		return
+	}
+	sm.driveBlocked = true
+	sm.log.Warn("driving blocked", "state", sm.state.String())
+	sm.publishFields(map[string]string{
+		"drive-blocked":     "true",
+		"drive-blocked-at":  strconv.FormatInt(time.Now().Unix(), 10),
+		"drive-released-by": "",
+	})
+}
+
+// releaseDrive lifts the block after an authorized disarm. by names what
+// authorized it (keycard, presence, setting, lost-mode).
+func (sm *StateMachine) releaseDrive(by string) {
+	if !sm.driveBlocked {
+		return
+	}
+	if err := sm.immobilizer.BlockDrive(false); err != nil {
+		// Stay blocked; the next authorized disarm retries.
+		sm.log.Error("failed to release driving block", "by", by, "error", err)
+		return
+	}
+	sm.driveBlocked = false
+	sm.log.Info("driving block released", "by", by)
+	sm.publishFields(map[string]string{
+		"drive-blocked":     "false",
+		"drive-released-by": by,
+	})
+}
+
+// restoreDriveBlock picks up a block the previous instance left in place.
+// The immobilizer setting arrives with the subscriber's initial sync, so
+// during init the decision waits for applyRestoredDriveBlock.
+func (sm *StateMachine) restoreDriveBlock() {
+	sm.driveBlockRestored = true
+	if sm.state != StateInit {
+		sm.applyRestoredDriveBlock()
+	}
+}
+
+// applyRestoredDriveBlock re-sends a restored block, in case vehicle-service
+// restarted as well, or clears it if the immobilizer has been turned off in
+// the meantime. As at runtime, lost mode keeps it regardless.
+func (sm *StateMachine) applyRestoredDriveBlock() {
+	if !sm.driveBlockRestored || sm.immobilizer == nil {
+		return
+	}
+	sm.driveBlockRestored = false
+	sm.driveBlocked = true
+	if !sm.immobilizerEnabled && !sm.lostMode {
+		sm.log.Info("immobilizer disabled, clearing persisted driving block")
+		sm.releaseDrive("setting")
+		return
+	}
+	sm.log.Warn("restoring driving block")
+	if err := sm.immobilizer.BlockDrive(true); err != nil {
+		sm.log.Error("failed to block driving", "error", err)
+	}
+}
+
+// keycardRelease lifts a driving block on an authorized keycard tap, the
+// only authorized disarm there is. Unlocking the vehicle is not, as that is
+// exactly what a hot-wired scooter looks like, and neither are the disarm
+// command or alarm.enabled=false, which any client on the bus can send. Nor
+// is trusted-device presence: it rests on the MAC address the BLE stack
+// reports, which can be spoofed or written to the ble hash directly. Lost
+// mode keeps the block until lost-mode:off.
+func (sm *StateMachine) keycardRelease() {
+	if sm.lostMode {
+		return
+	}
+	sm.releaseDrive("keycard")
+}
@@ -0,0 +1,180 @@
+package fsm
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+// Lost mode defaults.
+const (
+	defaultLostModeInterval = 10 // seconds between tracking updates
+	defaultLostModeBeacon   = 60 // seconds between hazard beacons
+)
+
+// setLostMode enters or leaves lost mode. Entering moves straight to
+// lost_mode from anywhere but init and a running L2 episode, which returns
+// to lost_mode when it ends. Leaving resumes the state the alarm setting
+// and vehicle state call for.
+func (sm *StateMachine) setLostMode(ctx context.Context, event Event, enabled, restored bool) {
+	if sm.lostMode == enabled {
+		return
+	}
+	sm.lostMode = enabled
+
+	fields := map[string]string{"lost-mode": strconv.FormatBool(enabled)}
+	switch {
+	case !enabled:
+		fields["lost-mode-since"] = ""
+	case !restored:
+		fields["lost-mode-since"] = strconv.FormatInt(time.Now().Unix(), 10)
+	}
+	sm.publishFields(fields)
+
+	if enabled {
+		sm.log.Warn("lost mode enabled", "state", sm.state.String(), "restored", restored)
+		if !restored {
+			sm.notifyDetail(NotificationLostMode, "enabled")
+		}
+	} else {
+		sm.log.Info("lost mode disabled", "state", sm.state.String())
+		sm.notifyDetail(NotificationLostMode, "disabled")
+	}
+
+	var newState State
+	switch {
+	case sm.state == StateInit:
+		// InitComplete picks the state once the caches are filled.
+		return
+	case enabled && (sm.state == StateTriggerLevel2 || sm.state == StateWaitingMovement):
+		return
+	case enabled:
+		newState = StateLostMode
+	default:
+		sm.stopTracking()
+		sm.releaseDrive("lost-mode")
+		newState = sm.resumeState()
+	}
+
+	oldState := sm.state
+	if newState == oldState {
+		return
+	}
+	sm.exitState(ctx, oldState)
+	sm.state = newState
+	sm.log.Info("state transition",
+		"from", oldState.String(),
+		"to", newState.String(),
+		"event", event.Type())
+	sm.enterState(ctx, newState)
+	sm.publishCurrentStatus()
+	sm.notifyTransition(oldState, newState, event)
+}
+
+// lostModeTransition replaces getTransition while lost mode is on: unlock
+// attempts, motion and tampering go straight to L2, and nothing short of an
+// authenticated command leaves lost mode. Vehicle and enable state are
+// still tracked so the FSM resumes correctly once lost mode ends.
+func (sm *StateMachine) lostModeTransition(event Event) State {
+	if e, ok := event.(VehicleStateChangedEvent); ok {
+		sm.vehicleStandby = (e.State == VehicleStateStandby)
+	}
+	if e, ok := event.(AlarmModeChangedEvent); ok {
+		sm.alarmEnabled = e.Enabled
+	}
+	if _, ok := event.(KeycardAuthorizedEvent); ok {
+		// The owner's card is no unlock attempt, but neither does it
+		// leave lost mode: that takes the signed lost-mode:off.
+		sm.log.Info("authorized keycard tap while lost", "state", sm.state.String())
+		return sm.state
+	}
+
+	switch sm.state {
+	case StateInit:
+		if be, ok := event.(BMXInterruptEvent); ok && be.Data == "wake-hibernation" {
+			sm.wakeFromHibernation = true
+		}
+		if _, ok := event.(InitCompleteEvent); ok {
+			return StateLostMode
+		}
+
+	case StateLostMode:
+		if isLostModeTrigger(event) {
+			sm.log.Warn("lost mode triggered", "event", event.Type())
+			return StateTriggerLevel2
+		}
+
+	case StateTriggerLevel2:
+		if _, ok := event.(Level2CheckTimerEvent); ok {
+			if sm.level2Cycles >= maxLevel2Cycles {
+				return StateLostMode
+			}
+			return StateWaitingMovement
+		}
+
+	case StateWaitingMovement:
+		if _, ok := event.(Level2CheckTimerEvent); ok {
+			return StateLostMode
+		}
+		if isLostModeTrigger(event) {
+			sm.level2Cycles++
+			if sm.level2Cycles >= maxLevel2Cycles {
+				return StateLostMode
+			}
+			return StateTriggerLevel2
+		}
+
+	default:
+		// setLostMode leaves no other state behind; settle in lost_mode
+		// should one slip through.
+		return StateLostMode
+	}
+
+	return sm.state
+}
+
+// isLostModeTrigger reports whether an event counts as an unlock attempt,
+// motion or tampering in lost mode.
+func isLostModeTrigger(event Event) bool {
+	switch e := event.(type) {
+	case BMXInterruptEvent, TowDetectedEvent, WheelTamperEvent, InputTamperEvent,
+		BatteryRemovedEvent, SeatboxOpenedEvent, UnauthorizedSeatboxEvent,
+		GeofenceBreachEvent, AuthFailureEvent, AuthFailureThresholdEvent:
+		return true
+	case VehicleStateChangedEvent:
+		return shouldDisarmForVehicleState(e.State)
+	}
+	return false
+}
+
+// refusedInLostMode reports whether an event is an unsigned command lost
+// mode refuses. Only the signed lost-mode:off may quiet or stop a lost
+// scooter, and a manual alarm would take the FSM out of lost_mode.
+func (sm *StateMachine) refusedInLostMode(event Event) bool {
+	switch event.(type) {
+	case SilenceEvent, ManualTriggerEvent, ManualStopEvent:
+		sm.log.Warn("refusing command in lost mode", "event", event.Type(), "state", sm.state.String())
+		return true
+	}
+	return false
+}
+
+// lostModeBeaconElapsed blinks the hazards and schedules the next beacon.
+func (sm *StateMachine) lostModeBeaconElapsed() {
+	if sm.state != StateLostMode {
+		return
+	}
+	sm.blinkHazards()
+	sm.scheduleLostModeBeacon()
+}
+
+// scheduleLostModeBeacon arms the timer for the next hazard beacon.
+func (sm *StateMachine) scheduleLostModeBeacon() {
+	sm.stopTimer("lost_mode_beacon")
+	if sm.lostModeBeacon <= 0 {
+		return
+	}
+	sm.startTimer("lost_mode_beacon", time.Duration(sm.lostModeBeacon)*time.Second, func() {
+		sm.SendEvent(LostModeBeaconTimerEvent{})
+	})
+}
@@ -0,0 +1,114 @@
+package fsm
+
+import "time"
+
+// Notification types forwarded to the telematics path.
+const (
+	NotificationArmed              = "armed"
+	NotificationLevel1             = "level-1-triggered"
+	NotificationLevel2             = "level-2-triggered"
+	NotificationSeatboxTamper      = "seatbox-tamper"
+	NotificationDisarmedAfterAlarm = "disarmed-after-alarm"
+	NotificationLevel2Exhausted    = "level-2-exhausted"
+	NotificationManualAlarm        = "manual-alarm"
+	NotificationGeofenceBreach     = "geofence-breach"
+	NotificationTracking           = "tracking"
+	NotificationWheelTamper        = "wheel-tamper"
+	NotificationBatteryTamper      = "battery-tamper"
+	NotificationAuthFailures       = "auth-failures"
+	NotificationTippedOver         = "tipped-over"
+	NotificationTowDetected        = "tow-detected"
+	NotificationLostMode           = "lost-mode" // detail "enabled" or "disabled"
+	NotificationUnlockedUnattended = "unlocked-unattended"
+	// Input tamper notifications are "<input>-tamper", e.g. "kickstand-tamper".
+)
+
+// Notification is an alarm event forwarded to the telematics path so the
+// owner hears about it off the scooter.
+type Notification struct {
+	Type      string
+	State     string
+	Timestamp time.Time
+	Silent    bool   // raised in silent mode, outputs were suppressed
+	Detail    string // type-specific detail, e.g. the keycard UID that disarmed
+	Position  Position
+	// Distance from the armed position in metres, valid if HasDistance.
+	Distance    float64
+	HasDistance bool
+}
+
+// Notifier forwards alarm notifications to the telematics path. Notify must
+// not block on the uplink; delivery is the Notifier's problem.
+type Notifier interface {
+	Notify(n Notification) error
+}
+
+// SetNotifier sets the Notifier alarm events are forwarded to.
+func (sm *StateMachine) SetNotifier(n Notifier) {
+	sm.notifier = n
+}
+
+// notify forwards a notification of the given type, if a Notifier is set.
+func (sm *StateMachine) notify(notificationType string) {
+	sm.notifyDetail(notificationType, "")
+}
+
+// notifyDetail forwards a notification with a type-specific detail.
+func (sm *StateMachine) notifyDetail(notificationType, detail string) {
+	if sm.notifier == nil {
+		return
+	}
+	n := Notification{
+		Type:      notificationType,
+		State:     sm.state.String(),
+		Timestamp: time.Now(),
+		Silent:    sm.silentMode,
+		Detail:    detail,
+		Position:  sm.position,
+	}
+	n.Distance, n.HasDistance = sm.distanceFromArmed()
+	if err := sm.notifier.Notify(n); err != nil {
+		sm.log.Error("failed to send notification", "type", notificationType, "error", err)
+	}
+}
+
+// notifyTransition raises the notification, if any, for a state transition.
+func (sm *StateMachine) notifyTransition(oldState, newState State, event Event) {
+	switch newState {
+	case StateArmed:
+		// Once per lock; re-arming after an L1 check is not news.
+		if !sm.armedNotified {
+			sm.armedNotified = true
+			sm.notify(NotificationArmed)
+		}
+	case StateTriggerLevel1Wait, StateTriggerLevel2:
+		if tamper, ok := tamperNotification(event); ok {
+			sm.notify(tamper)
+		} else if newState == StateTriggerLevel1Wait {
+			sm.notify(NotificationLevel1)
+		} else if oldState != StateWaitingMovement {
+			sm.notify(NotificationLevel2)
+		}
+	case StateManualAlarm:
+		sm.notify(NotificationManualAlarm)
+	case StateDisarmed, StateWaitingEnabled:
+		sm.armedNotified = false
+		if !isEpisodeState(oldState) {
+			return
+		}
+		_, checkTimer := event.(Level2CheckTimerEvent)
+		_, movement := event.(BMXInterruptEvent)
+		if _, tow := event.(TowDetectedEvent); tow {
+			movement = true
+		}
+		if (oldState == StateTriggerLevel2 && checkTimer) || (oldState == StateWaitingMovement && movement) {
+			sm.notify(NotificationLevel2Exhausted)
+			return
+		}
+		if e, ok := event.(KeycardAuthorizedEvent); ok {
+			sm.notifyDetail(NotificationDisarmedAfterAlarm, "keycard:"+e.UID)
+			return
+		}
+		sm.notify(NotificationDisarmedAfterAlarm)
+	}
+}
@@ -0,0 +1,48 @@
+package fsm
+
+// PresenceMode is what a connected trusted BLE device does to the alarm.
+type PresenceMode int
+
+const (
+	// PresenceNone means no trusted device is connected.
+	PresenceNone PresenceMode = iota
+	// PresenceSuppress keeps the alarm armed but ignores motion, so the
+	// owner loading the scooter doesn't keep tripping L1.
+	PresenceSuppress
+	// PresenceDisarm runtime-disarms while the device is connected and
+	// re-arms once it disconnects.
+	PresenceDisarm
+)
+
+func (m PresenceMode) String() string {
+	switch m {
+	case PresenceSuppress:
+		return "suppress"
+	case PresenceDisarm:
+		return "disarm"
+	default:
+		return "none"
+	}
+}
+
+// ParsePresenceMode parses "suppress" or "disarm".
+func ParsePresenceMode(s string) (PresenceMode, bool) {
+	switch s {
+	case "suppress":
+		return PresenceSuppress, true
+	case "disarm":
+		return PresenceDisarm, true
+	}
+	return PresenceNone, false
+}
+
+// setPresence records the trusted device now connected (PresenceNone when it
+// left) and publishes it.
+func (sm *StateMachine) setPresence(device string, mode PresenceMode) {
+	sm.presenceMode = mode
+	sm.log.Info("owner presence changed", "device", device, "mode", mode.String())
+	sm.publishFields(map[string]string{
+		"presence":      device,
+		"presence-mode": mode.String(),
+	})
+}
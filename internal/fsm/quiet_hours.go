@@ -0,0 +1,113 @@
+package fsm
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"alarm-service/internal/clock"
+)
+
+// QuietMode selects what an alarm sounds like during quiet hours.
+type QuietMode int
+
+const (
+	// QuietModeHazards flashes the hazards without the horn.
+	QuietModeHazards QuietMode = iota
+	// QuietModeShort honks a shortened burst of at most quietShortDuration.
+	QuietModeShort
+)
+
+func (m QuietMode) String() string {
+	switch m {
+	case QuietModeShort:
+		return "short"
+	default:
+		return "hazards"
+	}
+}
+
+// ParseQuietMode parses a string to QuietMode. Unknown values fall back to
+// hazards-only, the quieter choice.
+func ParseQuietMode(s string) QuietMode {
+	if s == "short" {
+		return QuietModeShort
+	}
+	return QuietModeHazards
+}
+
+// quietShortDuration caps the horn burst in QuietModeShort.
+const quietShortDuration = 3 * time.Second
+
+// QuietRange is a daily time-of-day range in minutes since midnight. Ranges
+// with End before Start wrap past midnight (e.g. 22:00-07:00).
+type QuietRange struct {
+	Start int
+	End   int
+}
+
+// ParseQuietHours parses a comma-separated list of HH:MM-HH:MM ranges, e.g.
+// "22:00-07:00" or "22:00-07:00,13:00-14:30". An empty string disables
+// quiet hours.
+func ParseQuietHours(s string) ([]QuietRange, error) {
+	var ranges []QuietRange
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		start, end, err := clock.ParseRange(part)
+		if err != nil {
+			return nil, fmt.Errorf("quiet hours range %q: %w", part, err)
+		}
+		ranges = append(ranges, QuietRange{Start: start, End: end})
+	}
+	return ranges, nil
+}
+
+// contains reports whether minute-of-day falls within the range.
+func (r QuietRange) contains(minute int) bool {
+	if r.Start < r.End {
+		return minute >= r.Start && minute < r.End
+	}
+	return minute >= r.Start || minute < r.End
+}
+
+// inQuietHours reports whether now, in loc, falls within any of the ranges.
+func inQuietHours(ranges []QuietRange, now time.Time, loc *time.Location) bool {
+	t := now.In(loc)
+	minute := t.Hour()*60 + t.Minute()
+	for _, r := range ranges {
+		if r.contains(minute) {
+			return true
+		}
+	}
+	return false
+}
+
+// nextQuietBoundary returns the next instant after now at which any range
+// starts or ends, or the zero time if there are no ranges.
+func nextQuietBoundary(ranges []QuietRange, now time.Time, loc *time.Location) time.Time {
+	t := now.In(loc)
+	var next time.Time
+	for _, r := range ranges {
+		for _, minute := range []int{r.Start, r.End} {
+			b := time.Date(t.Year(), t.Month(), t.Day(), minute/60, minute%60, 0, 0, loc)
+			if !b.After(t) {
+				b = b.AddDate(0, 0, 1)
+			}
+			if next.IsZero() || b.Before(next) {
+				next = b
+			}
+		}
+	}
+	return next
+}
+
+// quietHoursBoundary re-evaluates the audible mode as quiet hours start or
+// end, and schedules the next boundary.
+func (sm *StateMachine) quietHoursBoundary() {
+	sm.log.Info("quiet hours boundary", "quiet", sm.inQuietHours())
+	sm.scheduleQuietHoursTimer()
+	sm.publishAudibleMode()
+}
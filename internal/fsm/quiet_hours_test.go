@@ -0,0 +1,75 @@
+package fsm
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseQuietHours(t *testing.T) {
+	ranges, err := ParseQuietHours("22:00-07:00, 13:00-14:30")
+	if err != nil {
+		t.Fatalf("ParseQuietHours failed: %v", err)
+	}
+	expected := []QuietRange{{Start: 22 * 60, End: 7 * 60}, {Start: 13 * 60, End: 14*60 + 30}}
+	if len(ranges) != len(expected) {
+		t.Fatalf("expected %d ranges, got %d", len(expected), len(ranges))
+	}
+	for i := range expected {
+		if ranges[i] != expected[i] {
+			t.Errorf("range %d: expected %+v, got %+v", i, expected[i], ranges[i])
+		}
+	}
+
+	if ranges, err := ParseQuietHours(""); err != nil || len(ranges) != 0 {
+		t.Errorf("expected empty spec to disable quiet hours, got %v, %v", ranges, err)
+	}
+
+	for _, bad := range []string{"22:00", "25:00-07:00", "22:00-22:00", "ab-07:00"} {
+		if _, err := ParseQuietHours(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestInQuietHours(t *testing.T) {
+	ranges, _ := ParseQuietHours("22:00-07:00")
+	loc := time.UTC
+
+	tests := []struct {
+		hour, minute int
+		expected     bool
+	}{
+		{21, 59, false},
+		{22, 0, true},
+		{3, 0, true},
+		{6, 59, true},
+		{7, 0, false},
+		{12, 0, false},
+	}
+
+	for _, tt := range tests {
+		now := time.Date(2026, 3, 1, tt.hour, tt.minute, 0, 0, loc)
+		if got := inQuietHours(ranges, now, loc); got != tt.expected {
+			t.Errorf("inQuietHours at %02d:%02d = %v, expected %v", tt.hour, tt.minute, got, tt.expected)
+		}
+	}
+}
+
+func TestNextQuietBoundary(t *testing.T) {
+	ranges, _ := ParseQuietHours("22:00-07:00")
+	loc := time.UTC
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, loc)
+	if next := nextQuietBoundary(ranges, now, loc); !next.Equal(time.Date(2026, 3, 1, 22, 0, 0, 0, loc)) {
+		t.Errorf("expected next boundary at 22:00, got %v", next)
+	}
+
+	now = time.Date(2026, 3, 1, 23, 0, 0, 0, loc)
+	if next := nextQuietBoundary(ranges, now, loc); !next.Equal(time.Date(2026, 3, 2, 7, 0, 0, 0, loc)) {
+		t.Errorf("expected next boundary at 07:00 next day, got %v", next)
+	}
+
+	if next := nextQuietBoundary(nil, now, loc); !next.IsZero() {
+		t.Errorf("expected zero boundary without ranges, got %v", next)
+	}
+}
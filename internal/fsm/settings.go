@@ -0,0 +1,129 @@
+package fsm
+
+import "time"
+
+// applySetting applies a settings change and reports whether event was one.
+// Settings never cause a transition by themselves.
+func (sm *StateMachine) applySetting(event Event) bool {
+	switch e := event.(type) {
+	case HornSettingChangedEvent:
+		sm.hornEnabled = e.Enabled
+		sm.alarmController.SetHornEnabled(e.Enabled)
+		sm.publishAudibleMode()
+
+	case SilentModeChangedEvent:
+		sm.silentMode = e.Silent
+		sm.log.Info("silent mode updated", "silent", e.Silent)
+		if e.Silent {
+			sm.alarmController.Stop()
+		}
+		sm.publishAudibleMode()
+
+	case QuietHoursChangedEvent:
+		sm.quietHours = e.Ranges
+		sm.log.Info("quiet hours updated", "ranges", len(e.Ranges))
+		sm.scheduleQuietHoursTimer()
+		sm.publishAudibleMode()
+
+	case QuietHoursTimezoneChangedEvent:
+		sm.quietLocation = e.Location
+		sm.log.Info("quiet hours timezone updated", "timezone", e.Location.String())
+		sm.scheduleQuietHoursTimer()
+		sm.publishAudibleMode()
+
+	case QuietHoursModeChangedEvent:
+		sm.quietMode = e.Mode
+		sm.log.Info("quiet hours mode updated", "mode", e.Mode.String())
+		sm.publishAudibleMode()
+
+	case HornBudgetChangedEvent:
+		sm.alarmController.SetHornBudget(
+			time.Duration(e.MaxPerHour)*time.Second,
+			time.Duration(e.MaxPerNight)*time.Second,
+			time.Duration(e.MinRest)*time.Second,
+		)
+
+	case HornNightChangedEvent:
+		sm.alarmController.SetHornNight(e.Start, e.End)
+
+	case AlarmDurationChangedEvent:
+		sm.alarmDuration = e.Duration
+		sm.log.Info("alarm duration updated", "duration", e.Duration)
+
+	case HairTriggerSettingChangedEvent:
+		sm.hairTriggerEnabled = e.Enabled
+		sm.log.Info("hair trigger setting updated", "enabled", e.Enabled)
+
+	case HairTriggerDurationChangedEvent:
+		sm.hairTriggerDuration = e.Duration
+		sm.log.Info("hair trigger duration updated", "duration", e.Duration)
+
+	case L1CooldownDurationChangedEvent:
+		sm.l1CooldownDuration = e.Duration
+		sm.log.Info("L1 cooldown duration updated", "duration", e.Duration)
+
+	case AuthFailureThresholdChangedEvent:
+		sm.authFailureThreshold = e.Threshold
+		sm.log.Info("auth failure threshold updated", "threshold", e.Threshold)
+
+	case AuthFailureWindowChangedEvent:
+		if e.Window > 0 {
+			sm.authFailureWindow = e.Window
+			sm.log.Info("auth failure window updated", "window", e.Window)
+		}
+
+	case AuthFailureLevelChangedEvent:
+		sm.authFailureLevel = e.Level
+		sm.log.Info("auth failure level updated", "level", e.Level.String())
+
+	case GeofenceRadiusChangedEvent:
+		sm.geofenceRadius = e.Radius
+		sm.log.Info("geofence radius updated", "radius", e.Radius)
+
+	case TrackingIntervalChangedEvent:
+		if e.Interval > 0 {
+			sm.trackingInterval = e.Interval
+			sm.log.Info("tracking interval updated", "interval", e.Interval)
+		}
+
+	case UnattendedTimeoutChangedEvent:
+		sm.unattendedTimeout = e.Timeout
+		sm.log.Info("unattended timeout updated", "timeout", e.Timeout)
+		sm.scheduleUnattended(sm.alarmEnabled)
+
+	case UnattendedHazardsChangedEvent:
+		sm.unattendedHazards = e.Enabled
+		sm.log.Info("unattended hazards setting updated", "enabled", e.Enabled)
+
+	case ArmingActionChangedEvent:
+		sm.armingAction = e.Action
+		sm.log.Info("arming preconditions setting updated", "action", e.Action)
+		if sm.state == StateDelayArmed {
+			sm.checkArming(false)
+		}
+
+	case ImmobilizerChangedEvent:
+		sm.immobilizerEnabled = e.Enabled
+		sm.log.Info("immobilizer setting updated", "enabled", e.Enabled)
+		if !e.Enabled && !sm.lostMode {
+			sm.releaseDrive("setting")
+		}
+
+	case LostModeIntervalChangedEvent:
+		if e.Interval > 0 {
+			sm.lostModeInterval = e.Interval
+			sm.log.Info("lost mode tracking interval updated", "interval", e.Interval)
+		}
+
+	case LostModeBeaconChangedEvent:
+		sm.lostModeBeacon = e.Interval
+		sm.log.Info("lost mode beacon interval updated", "interval", e.Interval)
+		if sm.state == StateLostMode {
+			sm.scheduleLostModeBeacon()
+		}
+
+	default:
+		return false
+	}
+	return true
+}
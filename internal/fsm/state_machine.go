@@ -3,6 +3,7 @@ package fsm
 import (
 	"context"
 	"log/slog"
+	"strconv"
 	"sync"
 	"time"
 )
@@ -21,6 +22,8 @@ const (
 	StateTriggerLevel2
 	StateWaitingMovement
 	StateSeatboxAccess
+	StateManualAlarm
+	StateLostMode
 )
 
 func (s State) String() string {
@@ -35,6 +38,8 @@ func (s State) String() string {
 		"trigger_level_2",
 		"waiting_movement",
 		"seatbox_access",
+		"manual_alarm",
+		"lost_mode",
 	}[s]
 }
 
@@ -65,33 +70,79 @@ func (s Sensitivity) String() string {
 // state, this targets roughly 10 minutes of alarm before the safety valve trips.
 const maxLevel2Cycles = 6
 
+// defaultSilenceDuration is the snooze window applied by a bare `silence`
+// command. Long enough to walk back to the scooter, short enough that a
+// forgotten snooze doesn't leave the scooter mute for the night.
+const defaultSilenceDuration = 5 * time.Minute
+
 // StateMachine implements the alarm FSM
 type StateMachine struct {
-	mu     sync.RWMutex
-	state  State
-	events chan Event
-	log    *slog.Logger
-	ctx    context.Context
+	mu        sync.RWMutex
+	state     State
+	events    chan Event
+	ready     chan struct{} // closed once the FSM has left init
+	readyOnce sync.Once
+	log       *slog.Logger
+	ctx       context.Context
 
 	motion          MotionRPC
 	publisher       StatusPublisher
 	inhibitor       SuspendInhibitor
 	alarmController AlarmController
 	powerCommander  PowerCommander
-
-	timers              map[string]*time.Timer
-	alarmEnabled        bool
-	vehicleStandby      bool
-	level2Cycles        int
-	requestDisarm       bool
-	alarmDuration       int
-	hairTriggerEnabled  bool
-	hairTriggerDuration int
-	l1CooldownDuration  int
-	preSeatboxState     State
-	seatboxLockClosed   bool
-	wakeFromHibernation bool // woken from hibernation by motion (motion-service stamp or live event)
-	hibernationImminent bool // pm-service signalled hibernation is imminent or in progress
+	notifier        Notifier
+	immobilizer     Immobilizer
+
+	timers                map[string]*time.Timer
+	alarmEnabled          bool
+	vehicleStandby        bool
+	level2Cycles          int
+	requestDisarm         bool
+	alarmDuration         int
+	hairTriggerEnabled    bool
+	hairTriggerDuration   int
+	l1CooldownDuration    int
+	preSeatboxState       State
+	seatboxLockClosed     bool
+	wakeFromHibernation   bool      // woken from hibernation by motion (motion-service stamp or live event)
+	hibernationImminent   bool      // pm-service signalled hibernation is imminent or in progress
+	silencedUntil         time.Time // horn muted until this instant; zero when not silenced
+	manualDuration        int       // seconds the current manual alarm runs for
+	resumeLevel2          bool      // previous instance died mid-L2; resume it after init
+	level2Exhausted       bool      // L2 gave up after maxLevel2Cycles; Disarmed starts the post-alarm cooldown
+	hornEnabled           bool
+	quietHours            []QuietRange
+	quietLocation         *time.Location
+	quietMode             QuietMode
+	silentMode            bool        // alarm.mode=silent: detect and notify, no horn or hazards
+	armedNotified         bool        // "armed" already sent since the last disarm
+	position              Position    // latest gps hash snapshot
+	armedPosition         Position    // where the scooter was armed; zero until a fix
+	geofenceRadius        int         // metres; 0 disables the geofence
+	trackingInterval      int         // seconds between tracking updates
+	tracking              bool        // geofence breached, publishing positions until disarmed
+	authFailures          []time.Time // failed unlock attempts within the window
+	authFailureThreshold  int
+	authFailureWindow     int // seconds
+	authFailureLevel      TamperLevel
+	authFailuresPublished bool // auth-failures is non-zero in the alarm hash
+	presenceMode          PresenceMode
+	presenceDisarmed      bool // disarmed by a trusted device; re-arm when it leaves
+	tippedOver            bool // motion-service reports the scooter on its side
+	lostMode              bool // owner reported the scooter stolen; persisted in the alarm hash
+	lostModeInterval      int  // seconds between tracking updates in lost mode
+	lostModeBeacon        int  // seconds between hazard beacons in lost mode, 0 disables
+	immobilizerEnabled    bool // block driving during L2 and lost mode
+	driveBlocked          bool // block-drive sent and not yet released
+	driveBlockRestored    bool // persisted block to re-apply once init completes
+	vehicleParked         bool // vehicle state is parked: unlocked, not driving
+	unattendedTimeout     int  // seconds parked without rider activity before reminding, 0 disables
+	unattendedHazards     bool // blink hazards with the unattended reminder
+	unattended            bool // unattended reminder raised
+	armingFaults          map[ArmingFault]bool
+	armingAction          ArmingAction
+	armingRefused         bool   // holding in delay_armed on failed preconditions
+	armingBlocked         string // arming-blocked as last published
 }
 
 // MotionRPC is the synchronous motion-service interface alarm-service needs:
@@ -105,6 +156,8 @@ type MotionRPC interface {
 // StatusPublisher interface for publishing alarm status
 type StatusPublisher interface {
 	PublishStatus(status string) error
+	PublishField(field, value string) error
+	PublishFields(fields map[string]string) error
 }
 
 // SuspendInhibitor interface for managing wake locks
@@ -121,9 +174,13 @@ type PowerCommander interface {
 // AlarmController interface for horn and hazard lights
 type AlarmController interface {
 	Start(duration time.Duration) error
+	StartHazardsOnly(duration time.Duration) error
 	Stop() error
 	SetHornEnabled(enabled bool)
+	SetHornBudget(maxPerHour, maxPerNight, minRest time.Duration)
+	SetHornNight(start, end int)
 	BlinkHazards() error
+	BlinkTipOver() error
 }
 
 // New creates a new StateMachine
@@ -137,25 +194,37 @@ func New(
 	log *slog.Logger,
 ) *StateMachine {
 	return &StateMachine{
-		state:               StateInit,
-		events:              make(chan Event, 100),
-		log:                 log,
-		motion:              motion,
-		publisher:           pub,
-		inhibitor:           inh,
-		alarmController:     alarm,
-		powerCommander:      power,
-		timers:              make(map[string]*time.Timer),
-		alarmEnabled:        false,
-		vehicleStandby:      false,
-		level2Cycles:        0,
-		requestDisarm:       false,
-		alarmDuration:       alarmDuration,
-		hairTriggerEnabled:  false,
-		hairTriggerDuration: 3,
-		l1CooldownDuration:  5,
-		preSeatboxState:     StateInit,
-		seatboxLockClosed:   true,
+		state:                StateInit,
+		events:               make(chan Event, 100),
+		ready:                make(chan struct{}),
+		log:                  log,
+		motion:               motion,
+		publisher:            pub,
+		inhibitor:            inh,
+		alarmController:      alarm,
+		powerCommander:       power,
+		timers:               make(map[string]*time.Timer),
+		armingFaults:         make(map[ArmingFault]bool),
+		alarmEnabled:         false,
+		vehicleStandby:       false,
+		level2Cycles:         0,
+		requestDisarm:        false,
+		alarmDuration:        alarmDuration,
+		hairTriggerEnabled:   false,
+		hairTriggerDuration:  3,
+		l1CooldownDuration:   5,
+		preSeatboxState:      StateInit,
+		seatboxLockClosed:    true,
+		quietLocation:        time.Local,
+		quietMode:            QuietModeHazards,
+		geofenceRadius:       defaultGeofenceRadius,
+		trackingInterval:     defaultTrackingInterval,
+		lostModeInterval:     defaultLostModeInterval,
+		lostModeBeacon:       defaultLostModeBeacon,
+		unattendedTimeout:    defaultUnattendedTimeout,
+		authFailureThreshold: defaultAuthFailureThreshold,
+		authFailureWindow:    defaultAuthFailureWindow,
+		authFailureLevel:     TamperLevelL1,
 	}
 }
 
@@ -177,6 +246,12 @@ func (sm *StateMachine) Run(ctx context.Context) {
 	}
 }
 
+// Ready is closed once the FSM has left init, that is once the subscriber's
+// initial sync has been processed and runtime commands take effect.
+func (sm *StateMachine) Ready() <-chan struct{} {
+	return sm.ready
+}
+
 // SendEvent sends an event to the state machine
 func (sm *StateMachine) SendEvent(event Event) {
 	select {
@@ -192,6 +267,20 @@ func (sm *StateMachine) RuntimeArm() { sm.SendEvent(RuntimeArmEvent{}) }
 // RuntimeDisarm implements alarm.RuntimeCommander — forces disarming without changing alarm.enabled
 func (sm *StateMachine) RuntimeDisarm() { sm.SendEvent(RuntimeDisarmEvent{}) }
 
+// Silence implements alarm.RuntimeCommander — ends the current episode's
+// audible outputs and mutes the horn for a snooze window while staying armed
+func (sm *StateMachine) Silence(seconds int) { sm.SendEvent(SilenceEvent{Duration: seconds}) }
+
+// ManualStart implements alarm.RuntimeCommander — starts a manual alarm
+func (sm *StateMachine) ManualStart(seconds int) { sm.SendEvent(ManualTriggerEvent{Duration: seconds}) }
+
+// ManualStop implements alarm.RuntimeCommander — stops a manual alarm
+func (sm *StateMachine) ManualStop() { sm.SendEvent(ManualStopEvent{}) }
+
+// LostMode implements alarm.RuntimeCommander — enters or leaves lost mode
+// after the controller authenticated the command
+func (sm *StateMachine) LostMode(enabled bool) { sm.SendEvent(LostModeEvent{Enabled: enabled}) }
+
 // State returns the current state
 func (sm *StateMachine) State() State {
 	sm.mu.RLock()
@@ -199,102 +288,40 @@ func (sm *StateMachine) State() State {
 	return sm.state
 }
 
-// handleEvent processes an event
+// handleEvent processes an event: settings are applied, events that feed a
+// feature are handed to it, and the rest drive the state transition.
 func (sm *StateMachine) handleEvent(ctx context.Context, event Event) {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
-	if e, ok := event.(HornSettingChangedEvent); ok {
-		sm.alarmController.SetHornEnabled(e.Enabled)
-		return
-	}
-
-	if e, ok := event.(AlarmDurationChangedEvent); ok {
-		sm.alarmDuration = e.Duration
-		sm.log.Info("alarm duration updated", "duration", e.Duration)
-		return
-	}
-
-	if e, ok := event.(HairTriggerSettingChangedEvent); ok {
-		sm.hairTriggerEnabled = e.Enabled
-		sm.log.Info("hair trigger setting updated", "enabled", e.Enabled)
-		return
-	}
-
-	if e, ok := event.(HairTriggerDurationChangedEvent); ok {
-		sm.hairTriggerDuration = e.Duration
-		sm.log.Info("hair trigger duration updated", "duration", e.Duration)
+	if sm.applySetting(event) {
 		return
 	}
-
-	if e, ok := event.(L1CooldownDurationChangedEvent); ok {
-		sm.l1CooldownDuration = e.Duration
-		sm.log.Info("L1 cooldown duration updated", "duration", e.Duration)
+	if sm.lostMode && sm.refusedInLostMode(event) {
 		return
 	}
-
-	if e, ok := event.(HibernationImminentEvent); ok {
-		if sm.hibernationImminent == e.Imminent {
-			return
-		}
-		sm.hibernationImminent = e.Imminent
-		sm.log.Info("hibernation-imminent flag updated", "imminent", e.Imminent)
-		if e.Imminent && sm.state == StateArmed {
-			sm.confirmHibernationProfile(ctx)
-		}
+	if sm.dispatch(ctx, event) {
 		return
 	}
 
-	if _, ok := event.(HibernateAfterWakeTimerEvent); ok {
-		if sm.state == StateArmed && sm.wakeFromHibernation && sm.vehicleStandby {
-			sm.wakeFromHibernation = false
-			sm.log.Info("hibernate cooldown elapsed, requesting re-hibernate")
-			if err := sm.powerCommander.RequestHibernate(); err != nil {
-				sm.log.Error("failed to request hibernation", "error", err)
-			}
-		}
-		return
-	}
-
-	if _, ok := event.(PostAlarmCooldownTimerEvent); ok {
-		if sm.state != StateDisarmed || !sm.alarmEnabled || !sm.vehicleStandby {
-			return
-		}
-		if sm.wakeFromHibernation {
-			// Transition into StateArmed so the BMX is properly configured for
-			// motion detection (and the nRF52 has something to wake on once we
-			// hibernate), then request hibernate. Clear the flag first so
-			// onEnterArmed doesn't start another 5-min cooldown.
-			sm.wakeFromHibernation = false
-			sm.log.Info("post-alarm cooldown elapsed, arming and requesting re-hibernate")
-			sm.exitState(ctx, StateDisarmed)
-			sm.state = StateArmed
-			sm.enterState(ctx, StateArmed)
-			sm.publishCurrentStatus()
-			if err := sm.powerCommander.RequestHibernate(); err != nil {
-				sm.log.Error("failed to request hibernation", "error", err)
-			}
-			return
-		}
-		// Fall through to normal transition handling so the FSM re-arms via
-		// the StateDisarmed handler below (Disarmed → DelayArmed → Armed).
-	}
-
 	oldState := sm.state
 	sm.log.Debug("handling event",
 		"event", event.Type(),
 		"state", oldState.String())
 
-	newState := sm.getTransition(event)
+	var newState State
+	if sm.lostMode {
+		newState = sm.lostModeTransition(event)
+	} else {
+		newState = sm.getTransition(event)
+	}
 
 	if newState != oldState {
 		// Blink hazards when movement detected during L1 (before L2 activation)
 		if oldState == StateTriggerLevel1 && newState == StateTriggerLevel2 {
 			if _, ok := event.(BMXInterruptEvent); ok {
 				sm.log.Info("movement detected during L1, blinking hazards")
-				if err := sm.alarmController.BlinkHazards(); err != nil {
-					sm.log.Error("failed to blink hazards", "error", err)
-				}
+				sm.blinkHazards()
 			}
 		}
 
@@ -306,7 +333,302 @@ func (sm *StateMachine) handleEvent(ctx context.Context, event Event) {
 			"event", event.Type())
 		sm.enterState(ctx, newState)
 		sm.publishCurrentStatus()
+		sm.notifyTransition(oldState, newState, event)
+	}
+}
+
+// dispatch hands an event to the feature it belongs to and reports whether
+// that fully handled it. Events it returns false for go on to the state
+// transition.
+func (sm *StateMachine) dispatch(ctx context.Context, event Event) bool {
+	switch e := event.(type) {
+	case GPSUpdateEvent:
+		sm.updatePosition(e.Position)
+	case TrackingTimerEvent:
+		sm.trackingElapsed()
+	case GeofenceBreachEvent:
+		sm.onGeofenceBreach(e.Distance)
+		return false
+	case AuthFailureEvent:
+		if !sm.onAuthFailure(e.Source) {
+			return true
+		}
+		// Lost mode: any failed unlock attempt escalates on its own.
+		return !sm.lostMode
+	case RuntimeDisarmEvent:
+		// An explicit disarm ends tracking even while the vehicle is still
+		// in stand-by.
+		if !sm.lostMode {
+			sm.stopTracking()
+		}
+		return false
+	case PresenceChangedEvent:
+		// PresenceDisarm disarms armed states, and a presence-disarmed
+		// scooter re-arms once the device leaves.
+		sm.setPresence(e.Device, e.Mode)
+		return false
+	case KeycardAuthorizedEvent:
+		sm.keycardRelease()
+		return false
+	case VehicleStateChangedEvent:
+		sm.onVehicleState(e.State)
+		return false
+	case AlarmModeChangedEvent:
+		sm.scheduleUnattended(e.Enabled)
+		return false
+	case RiderActivityEvent:
+		sm.onRiderActivity(e.Source)
+	case UnattendedTimerEvent:
+		sm.unattendedElapsed()
+	case ArmingFaultEvent:
+		sm.setArmingFault(e.Fault, e.Active)
+	case DriveBlockRestoredEvent:
+		sm.restoreDriveBlock()
+	case LostModeEvent:
+		sm.setLostMode(ctx, event, e.Enabled, e.Restored)
+	case LostModeBeaconTimerEvent:
+		sm.lostModeBeaconElapsed()
+	case TowDetectedEvent:
+		sm.onTowDetected(e)
+		return false
+	case TipOverEvent:
+		// Not an alarm trigger: worth knowing about armed or not.
+		sm.setTippedOver(e.TippedOver, e.Hazards)
+	case QuietHoursBoundaryTimerEvent:
+		sm.quietHoursBoundary()
+	case HibernationImminentEvent:
+		sm.setHibernationImminent(ctx, e.Imminent)
+	case SilenceEvent:
+		sm.silence(ctx, event, e.Duration)
+	case ManualTriggerEvent:
+		sm.manualTrigger(ctx, event, e.Duration)
+	case ManualStopEvent:
+		if sm.state == StateManualAlarm {
+			return false
+		}
+		// Nothing manual to end; keep the old behaviour of cutting whatever
+		// output is running without touching the FSM state.
+		sm.log.Info("stop requested outside manual alarm, stopping outputs", "state", sm.state.String())
+		sm.alarmController.Stop()
+	case SilenceExpiredTimerEvent:
+		sm.silenceElapsed()
+	case HibernateAfterWakeTimerEvent:
+		sm.hibernateAfterWake()
+	case PostAlarmCooldownTimerEvent:
+		return sm.postAlarmCooldownElapsed(ctx, event)
+	default:
+		return false
+	}
+	return true
+}
+
+// silence mutes the horn for the snooze window and, if an episode is running,
+// ends it by dropping back to delay_armed so motion detection carries on with
+// a fresh episode. Ignored while the alarm isn't protecting the scooter.
+func (sm *StateMachine) silence(ctx context.Context, event Event, seconds int) {
+	if sm.state == StateInit || sm.state == StateWaitingEnabled {
+		sm.log.Info("ignoring silence, alarm not enabled", "state", sm.state.String())
+		return
+	}
+
+	duration := defaultSilenceDuration
+	if seconds > 0 {
+		duration = time.Duration(seconds) * time.Second
+	}
+	sm.silencedUntil = time.Now().Add(duration)
+	sm.startTimer("silence", duration, func() {
+		sm.SendEvent(SilenceExpiredTimerEvent{})
+	})
+	sm.publishSilence()
+	sm.log.Info("alarm silenced", "duration", duration, "state", sm.state.String())
+	sm.alarmController.Stop()
+
+	if !isEpisodeState(sm.state) {
+		return
+	}
+
+	oldState := sm.state
+	sm.exitState(ctx, oldState)
+	sm.state = StateDelayArmed
+	sm.log.Info("state transition",
+		"from", oldState.String(),
+		"to", sm.state.String(),
+		"event", event.Type())
+	sm.enterState(ctx, sm.state)
+	sm.publishCurrentStatus()
+}
+
+// manualTrigger enters the manual alarm state, or restarts it with the new
+// duration if a manual alarm is already running. It is refused before init
+// completes and while an episode or seatbox access is in progress: the
+// manual alarm would end it, and resumeState can't bring either back.
+func (sm *StateMachine) manualTrigger(ctx context.Context, event Event, seconds int) {
+	if sm.state == StateInit {
+		sm.log.Warn("ignoring manual alarm before init completed")
+		return
+	}
+	if isEpisodeState(sm.state) || sm.state == StateSeatboxAccess {
+		sm.log.Warn("ignoring manual alarm, would end the current state", "state", sm.state.String())
+		return
+	}
+
+	if seconds <= 0 {
+		seconds = sm.alarmDuration
+	}
+	sm.manualDuration = seconds
+
+	oldState := sm.state
+	if oldState == StateManualAlarm {
+		sm.log.Info("restarting manual alarm", "duration", seconds)
+		sm.enterState(ctx, StateManualAlarm)
+		return
+	}
+
+	sm.exitState(ctx, oldState)
+	sm.state = StateManualAlarm
+	sm.log.Info("state transition",
+		"from", oldState.String(),
+		"to", sm.state.String(),
+		"event", event.Type())
+	sm.enterState(ctx, sm.state)
+	sm.publishCurrentStatus()
+	sm.notifyTransition(oldState, sm.state, event)
+}
+
+// resumeState picks where to go once a manual alarm is over, based on the
+// alarm setting and vehicle state tracked while it ran.
+func (sm *StateMachine) resumeState() State {
+	if !sm.alarmEnabled {
+		return StateWaitingEnabled
+	}
+	if sm.vehicleStandby {
+		return StateDelayArmed
+	}
+	return StateDisarmed
+}
+
+// silenceElapsed re-enables the horn once the snooze window is over.
+func (sm *StateMachine) silenceElapsed() {
+	if sm.silencedUntil.IsZero() || time.Now().Before(sm.silencedUntil) {
+		return
+	}
+	sm.log.Info("silence window elapsed, horn re-enabled")
+	sm.clearSilence()
+}
+
+// clearSilence ends any snooze window.
+func (sm *StateMachine) clearSilence() {
+	sm.stopTimer("silence")
+	if sm.silencedUntil.IsZero() {
+		return
+	}
+	sm.silencedUntil = time.Time{}
+	sm.publishSilence()
+}
+
+// isSilenced reports whether the horn is currently muted by a snooze window.
+func (sm *StateMachine) isSilenced() bool {
+	return !sm.silencedUntil.IsZero() && time.Now().Before(sm.silencedUntil)
+}
+
+// publishSilence publishes the snooze deadline (unix seconds, empty when not silenced).
+func (sm *StateMachine) publishSilence() {
+	value := ""
+	if !sm.silencedUntil.IsZero() {
+		value = strconv.FormatInt(sm.silencedUntil.Unix(), 10)
 	}
+	if err := sm.publisher.PublishField("silenced-until", value); err != nil {
+		sm.log.Error("failed to publish silenced-until", "error", err)
+	}
+	sm.publishAudibleMode()
+}
+
+// startAlarm starts the horn + hazard pattern, toned down while silenced or
+// during quiet hours. alarm.honk stays the master switch in the controller.
+func (sm *StateMachine) startAlarm(duration time.Duration) {
+	if sm.silentMode {
+		sm.log.Info("silent mode, suppressing alarm outputs", "duration", duration)
+		return
+	}
+	if sm.isSilenced() {
+		sm.log.Info("alarm silenced, starting hazards only", "duration", duration)
+		sm.alarmController.StartHazardsOnly(duration)
+		return
+	}
+	if sm.inQuietHours() {
+		if sm.quietMode == QuietModeShort {
+			duration = min(duration, quietShortDuration)
+			sm.log.Info("quiet hours, starting short alarm", "duration", duration)
+			sm.alarmController.Start(duration)
+			return
+		}
+		sm.log.Info("quiet hours, starting hazards only", "duration", duration)
+		sm.alarmController.StartHazardsOnly(duration)
+		return
+	}
+	sm.alarmController.Start(duration)
+}
+
+// blinkHazards flashes the hazards as an L1 warning unless silent mode
+// suppresses outputs.
+func (sm *StateMachine) blinkHazards() {
+	if sm.silentMode {
+		sm.log.Info("silent mode, suppressing hazard blink")
+		return
+	}
+	if err := sm.alarmController.BlinkHazards(); err != nil {
+		sm.log.Error("failed to blink hazards", "error", err)
+	}
+}
+
+// inQuietHours reports whether quiet hours are in effect right now.
+func (sm *StateMachine) inQuietHours() bool {
+	return inQuietHours(sm.quietHours, time.Now(), sm.quietLocation)
+}
+
+// scheduleQuietHoursTimer arms a timer for the next quiet hours boundary so
+// the published audible mode flips on time.
+func (sm *StateMachine) scheduleQuietHoursTimer() {
+	sm.stopTimer("quiet_hours")
+	next := nextQuietBoundary(sm.quietHours, time.Now(), sm.quietLocation)
+	if next.IsZero() {
+		return
+	}
+	sm.startTimer("quiet_hours", time.Until(next), func() {
+		sm.SendEvent(QuietHoursBoundaryTimerEvent{})
+	})
+}
+
+// audibleMode describes what an alarm started now would sound like.
+func (sm *StateMachine) audibleMode() string {
+	switch {
+	case sm.silentMode:
+		return "silent"
+	case !sm.hornEnabled:
+		return "horn-disabled"
+	case sm.isSilenced():
+		return "silenced"
+	case sm.inQuietHours():
+		return "quiet-" + sm.quietMode.String()
+	default:
+		return "normal"
+	}
+}
+
+// publishAudibleMode publishes the current audible mode
+func (sm *StateMachine) publishAudibleMode() {
+	if err := sm.publisher.PublishField("audible-mode", sm.audibleMode()); err != nil {
+		sm.log.Error("failed to publish audible mode", "error", err)
+	}
+}
+
+// isEpisodeState reports whether the state belongs to a running alarm episode.
+func isEpisodeState(state State) bool {
+	switch state {
+	case StateTriggerLevel1Wait, StateTriggerLevel1, StateTriggerLevel2, StateWaitingMovement:
+		return true
+	}
+	return false
 }
 
 // publishCurrentStatus publishes the current alarm status
@@ -315,6 +637,7 @@ func (sm *StateMachine) publishCurrentStatus() {
 	if err := sm.publisher.PublishStatus(status); err != nil {
 		sm.log.Error("failed to publish status", "error", err)
 	}
+	sm.publishAudibleMode()
 }
 
 // stateToStatus converts state to status string
@@ -334,11 +657,70 @@ func (sm *StateMachine) stateToStatus(state State) string {
 		return "level-2-triggered"
 	case StateSeatboxAccess:
 		return "seatbox-access"
+	case StateManualAlarm:
+		return "manual-alarm"
+	case StateLostMode:
+		// motion-service picks its sensor profile from the status; lost
+		// mode needs the armed one. The lost-mode field tells them apart.
+		return "armed"
 	default:
 		return "unknown"
 	}
 }
 
+// setHibernationImminent tracks pm-service's hibernation-imminent flag and
+// confirms the hibernation profile when it is raised while armed.
+func (sm *StateMachine) setHibernationImminent(ctx context.Context, imminent bool) {
+	if sm.hibernationImminent == imminent {
+		return
+	}
+	sm.hibernationImminent = imminent
+	sm.log.Info("hibernation-imminent flag updated", "imminent", imminent)
+	if imminent && sm.state == StateArmed {
+		sm.confirmHibernationProfile(ctx)
+	}
+}
+
+// hibernateAfterWake asks for hibernation again once the cooldown after a
+// wake from hibernation has passed without an episode.
+func (sm *StateMachine) hibernateAfterWake() {
+	if sm.state != StateArmed || !sm.wakeFromHibernation || !sm.vehicleStandby {
+		return
+	}
+	sm.wakeFromHibernation = false
+	sm.log.Info("hibernate cooldown elapsed, requesting re-hibernate")
+	if err := sm.powerCommander.RequestHibernate(); err != nil {
+		sm.log.Error("failed to request hibernation", "error", err)
+	}
+}
+
+// postAlarmCooldownElapsed re-arms after the post-alarm cooldown. It reports
+// whether it handled the event; otherwise the FSM re-arms via the
+// StateDisarmed transition (Disarmed → DelayArmed → Armed).
+func (sm *StateMachine) postAlarmCooldownElapsed(ctx context.Context, event Event) bool {
+	if sm.state != StateDisarmed || !sm.alarmEnabled || !sm.vehicleStandby {
+		return true
+	}
+	if !sm.wakeFromHibernation {
+		return false
+	}
+	// Transition into StateArmed so the BMX is properly configured for
+	// motion detection (and the nRF52 has something to wake on once we
+	// hibernate), then request hibernate. Clear the flag first so
+	// onEnterArmed doesn't start another 5-min cooldown.
+	sm.wakeFromHibernation = false
+	sm.log.Info("post-alarm cooldown elapsed, arming and requesting re-hibernate")
+	sm.exitState(ctx, StateDisarmed)
+	sm.state = StateArmed
+	sm.enterState(ctx, StateArmed)
+	sm.publishCurrentStatus()
+	sm.notifyTransition(StateDisarmed, StateArmed, event)
+	if err := sm.powerCommander.RequestHibernate(); err != nil {
+		sm.log.Error("failed to request hibernation", "error", err)
+	}
+	return true
+}
+
 // confirmHibernationProfile is the synchronous handshake that gates pm-service's
 // suspend on motion-service having the chip in armed-hibernation profile. Called
 // when hibernationImminent flips to true while we're in StateArmed. Steady-state
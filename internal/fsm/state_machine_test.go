@@ -3,7 +3,9 @@ package fsm
 import (
 	"context"
 	"log/slog"
+	"math"
 	"os"
+	"slices"
 	"testing"
 	"time"
 )
@@ -21,6 +23,7 @@ func (m *mockMotionRPC) PrepareHibernation(ctx context.Context) error {
 
 type mockStatusPublisher struct {
 	lastStatus string
+	fields     map[string]string
 }
 
 func (m *mockStatusPublisher) PublishStatus(status string) error {
@@ -28,6 +31,21 @@ func (m *mockStatusPublisher) PublishStatus(status string) error {
 	return nil
 }
 
+func (m *mockStatusPublisher) PublishField(field, value string) error {
+	if m.fields == nil {
+		m.fields = make(map[string]string)
+	}
+	m.fields[field] = value
+	return nil
+}
+
+func (m *mockStatusPublisher) PublishFields(fields map[string]string) error {
+	for field, value := range fields {
+		m.PublishField(field, value)
+	}
+	return nil
+}
+
 type mockSuspendInhibitor struct {
 	acquired bool
 	reason   string
@@ -46,14 +64,26 @@ func (m *mockSuspendInhibitor) Release() error {
 }
 
 type mockAlarmController struct {
-	active      bool
-	duration    time.Duration
-	hornEnabled bool
-	blinkCalled int
+	active        bool
+	hazardsOnly   bool
+	duration      time.Duration
+	hornEnabled   bool
+	hornBudget    [3]time.Duration
+	hornNight     [2]int
+	blinkCalled   int
+	tipOverBlinks int
 }
 
 func (m *mockAlarmController) Start(duration time.Duration) error {
 	m.active = true
+	m.hazardsOnly = false
+	m.duration = duration
+	return nil
+}
+
+func (m *mockAlarmController) StartHazardsOnly(duration time.Duration) error {
+	m.active = true
+	m.hazardsOnly = true
 	m.duration = duration
 	return nil
 }
@@ -67,11 +97,33 @@ func (m *mockAlarmController) SetHornEnabled(enabled bool) {
 	m.hornEnabled = enabled
 }
 
+func (m *mockAlarmController) SetHornBudget(maxPerHour, maxPerNight, minRest time.Duration) {
+	m.hornBudget = [3]time.Duration{maxPerHour, maxPerNight, minRest}
+}
+
+func (m *mockAlarmController) SetHornNight(start, end int) {
+	m.hornNight = [2]int{start, end}
+}
+
 func (m *mockAlarmController) BlinkHazards() error {
 	m.blinkCalled++
 	return nil
 }
 
+func (m *mockAlarmController) BlinkTipOver() error {
+	m.tipOverBlinks++
+	return nil
+}
+
+type mockNotifier struct {
+	notifications []Notification
+}
+
+func (m *mockNotifier) Notify(n Notification) error {
+	m.notifications = append(m.notifications, n)
+	return nil
+}
+
 type mockPowerCommander struct {
 	hibernateCalled int
 }
@@ -81,6 +133,15 @@ func (m *mockPowerCommander) RequestHibernate() error {
 	return nil
 }
 
+type mockImmobilizer struct {
+	commands []bool
+}
+
+func (m *mockImmobilizer) BlockDrive(blocked bool) error {
+	m.commands = append(m.commands, blocked)
+	return nil
+}
+
 func createTestStateMachine() (*StateMachine, *mockMotionRPC, *mockStatusPublisher, *mockSuspendInhibitor, *mockAlarmController) {
 	sm, motion, pub, inh, alarm, _ := createTestStateMachineWithPower()
 	return sm, motion, pub, inh, alarm
@@ -473,13 +534,235 @@ func TestStateMachine_ManualTriggerFromArmed(t *testing.T) {
 	sm.SendEvent(ManualTriggerEvent{Duration: 15})
 	sm.handleEvent(ctx, <-sm.events)
 
-	if sm.State() != StateTriggerLevel2 {
-		t.Errorf("expected StateTriggerLevel2, got %s", sm.State())
+	if sm.State() != StateManualAlarm {
+		t.Errorf("expected StateManualAlarm, got %s", sm.State())
 	}
 
 	if !alarm.active {
 		t.Error("expected alarm to be active")
 	}
+
+	if alarm.duration != 15*time.Second {
+		t.Errorf("expected manual alarm duration 15s, got %v", alarm.duration)
+	}
+	sm.cleanupTimers()
+}
+
+func TestStateMachine_ManualAlarmEscalatesOnTrigger(t *testing.T) {
+	for _, event := range []Event{
+		BMXInterruptEvent{},
+		UnauthorizedSeatboxEvent{},
+		BatteryRemovedEvent{},
+		InputTamperEvent{Input: TamperInputBrake, Level: TamperLevelL1},
+	} {
+		sm, _, _, _, alarm := createTestStateMachine()
+		ctx := context.Background()
+
+		sm.state = StateArmed
+		sm.alarmEnabled = true
+		sm.vehicleStandby = true
+
+		sm.SendEvent(ManualTriggerEvent{Duration: 600})
+		sm.handleEvent(ctx, <-sm.events)
+		if sm.State() != StateManualAlarm {
+			t.Fatalf("expected StateManualAlarm, got %s", sm.State())
+		}
+
+		sm.SendEvent(event)
+		sm.handleEvent(ctx, <-sm.events)
+		if sm.State() != StateTriggerLevel2 {
+			t.Errorf("%s: expected the trigger to escalate to L2, got %s", event.Type(), sm.State())
+		}
+		if !alarm.active {
+			t.Errorf("%s: expected the alarm to keep sounding", event.Type())
+		}
+		sm.cleanupTimers()
+	}
+}
+
+func TestStateMachine_ManualAlarmIgnoresTriggerWhenUnlocked(t *testing.T) {
+	sm, _, _, _, _ := createTestStateMachine()
+	ctx := context.Background()
+
+	sm.state = StateDisarmed
+	sm.alarmEnabled = true
+	sm.vehicleStandby = false
+
+	sm.SendEvent(ManualTriggerEvent{Duration: 60})
+	sm.handleEvent(ctx, <-sm.events)
+	sm.SendEvent(BMXInterruptEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+	if sm.State() != StateManualAlarm {
+		t.Errorf("expected motion on an unlocked scooter not to escalate, got %s", sm.State())
+	}
+	sm.cleanupTimers()
+}
+
+func TestStateMachine_ManualTriggerFromAnyState(t *testing.T) {
+	states := []State{
+		StateWaitingEnabled,
+		StateDisarmed,
+		StateDelayArmed,
+		StateArmed,
+	}
+
+	for _, initialState := range states {
+		sm, _, pub, inh, alarm := createTestStateMachine()
+		ctx := context.Background()
+
+		sm.state = initialState
+
+		sm.SendEvent(ManualTriggerEvent{})
+		sm.handleEvent(ctx, <-sm.events)
+
+		if sm.State() != StateManualAlarm {
+			t.Errorf("expected StateManualAlarm from %s, got %s", initialState, sm.State())
+		}
+		if !inh.acquired {
+			t.Errorf("expected inhibitor acquired for manual alarm from %s", initialState)
+		}
+		if pub.lastStatus != "manual-alarm" {
+			t.Errorf("expected status 'manual-alarm', got %s", pub.lastStatus)
+		}
+		if alarm.duration != 10*time.Second {
+			t.Errorf("expected default alarm duration 10s, got %v", alarm.duration)
+		}
+		sm.cleanupTimers()
+	}
+}
+
+func TestStateMachine_ManualTriggerRefusedDuringEpisode(t *testing.T) {
+	states := []State{
+		StateTriggerLevel1Wait,
+		StateTriggerLevel1,
+		StateTriggerLevel2,
+		StateWaitingMovement,
+	}
+
+	for _, initialState := range states {
+		sm, _, _, _, _ := createTestStateMachine()
+		ctx := context.Background()
+
+		sm.state = initialState
+		sm.alarmEnabled = true
+		sm.vehicleStandby = true
+
+		sm.SendEvent(ManualTriggerEvent{Duration: 30})
+		sm.handleEvent(ctx, <-sm.events)
+
+		if sm.State() != initialState {
+			t.Errorf("expected manual alarm refused in %s, got %s", initialState, sm.State())
+		}
+		sm.cleanupTimers()
+	}
+}
+
+func TestStateMachine_ManualTriggerRefusedDuringSeatboxAccess(t *testing.T) {
+	sm, _, _, _, alarm := createTestStateMachine()
+	ctx := context.Background()
+
+	sm.state = StateArmed
+	sm.alarmEnabled = true
+	sm.vehicleStandby = true
+
+	sm.SendEvent(SeatboxOpenedEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+	if sm.State() != StateSeatboxAccess {
+		t.Fatalf("expected StateSeatboxAccess, got %s", sm.State())
+	}
+
+	sm.SendEvent(ManualTriggerEvent{Duration: 30})
+	sm.handleEvent(ctx, <-sm.events)
+	if sm.State() != StateSeatboxAccess {
+		t.Fatalf("expected manual alarm refused during seatbox access, got %s", sm.State())
+	}
+	if alarm.active {
+		t.Error("expected no alarm outputs")
+	}
+
+	// The authorization survives: closing the seatbox re-arms.
+	sm.SendEvent(SeatboxClosedEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+	if sm.State() != StateDelayArmed {
+		t.Errorf("expected seatbox close to resume arming, got %s", sm.State())
+	}
+	sm.cleanupTimers()
+}
+
+func TestStateMachine_ManualAlarmResumesOnStop(t *testing.T) {
+	tests := []struct {
+		name     string
+		enabled  bool
+		standby  bool
+		expected State
+	}{
+		{"disabled", false, true, StateWaitingEnabled},
+		{"enabled standby", true, true, StateDelayArmed},
+		{"enabled parked", true, false, StateDisarmed},
+	}
+
+	for _, tt := range tests {
+		sm, _, _, _, alarm := createTestStateMachine()
+		ctx := context.Background()
+
+		sm.state = StateManualAlarm
+		sm.alarmEnabled = tt.enabled
+		sm.vehicleStandby = tt.standby
+		alarm.active = true
+
+		sm.SendEvent(ManualStopEvent{})
+		sm.handleEvent(ctx, <-sm.events)
+
+		if sm.State() != tt.expected {
+			t.Errorf("%s: expected %s after manual stop, got %s", tt.name, tt.expected, sm.State())
+		}
+		if alarm.active {
+			t.Errorf("%s: expected alarm stopped", tt.name)
+		}
+		sm.cleanupTimers()
+	}
+}
+
+func TestStateMachine_ManualAlarmTracksVehicleState(t *testing.T) {
+	sm, _, _, _, _ := createTestStateMachine()
+	ctx := context.Background()
+
+	sm.state = StateManualAlarm
+	sm.alarmEnabled = true
+	sm.vehicleStandby = false
+
+	sm.SendEvent(VehicleStateChangedEvent{State: VehicleStateStandby})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if sm.State() != StateManualAlarm {
+		t.Fatalf("expected to stay in StateManualAlarm, got %s", sm.State())
+	}
+
+	sm.SendEvent(ManualAlarmTimerEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if sm.State() != StateDelayArmed {
+		t.Errorf("expected StateDelayArmed after manual alarm expired, got %s", sm.State())
+	}
+	sm.cleanupTimers()
+}
+
+func TestStateMachine_ManualStopOutsideManualAlarmStopsOutputs(t *testing.T) {
+	sm, _, _, _, alarm := createTestStateMachine()
+	ctx := context.Background()
+
+	sm.state = StateArmed
+	alarm.active = true
+
+	sm.SendEvent(ManualStopEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if sm.State() != StateArmed {
+		t.Errorf("expected state to remain StateArmed, got %s", sm.State())
+	}
+	if alarm.active {
+		t.Error("expected alarm outputs to be stopped")
+	}
 }
 
 func TestStateMachine_StateToStatus(t *testing.T) {
@@ -497,6 +780,7 @@ func TestStateMachine_StateToStatus(t *testing.T) {
 		{StateTriggerLevel1, "level-1-triggered"},
 		{StateTriggerLevel2, "level-2-triggered"},
 		{StateWaitingMovement, "level-2-triggered"},
+		{StateManualAlarm, "manual-alarm"},
 	}
 
 	for _, tt := range tests {
@@ -1073,3 +1357,1727 @@ func TestStateMachine_PostAlarmCooldownIgnoredWhenAlarmDisabled(t *testing.T) {
 		t.Errorf("expected to remain in StateDisarmed, got %s", sm.State())
 	}
 }
+
+func TestStateMachine_SilenceEndsEpisodeAndStaysArmed(t *testing.T) {
+	states := []State{
+		StateTriggerLevel1Wait,
+		StateTriggerLevel1,
+		StateTriggerLevel2,
+		StateWaitingMovement,
+	}
+
+	for _, initialState := range states {
+		sm, _, pub, _, alarm := createTestStateMachine()
+		ctx := context.Background()
+
+		sm.state = initialState
+		sm.alarmEnabled = true
+		sm.vehicleStandby = true
+		sm.level2Cycles = 3
+		alarm.active = true
+
+		sm.SendEvent(SilenceEvent{Duration: 60})
+		sm.handleEvent(ctx, <-sm.events)
+
+		if sm.State() != StateDelayArmed {
+			t.Errorf("expected StateDelayArmed after silence from %s, got %s", initialState, sm.State())
+		}
+		if alarm.active {
+			t.Errorf("expected alarm outputs stopped after silence from %s", initialState)
+		}
+		if sm.level2Cycles != 0 {
+			t.Errorf("expected episode to end (level2Cycles reset), got %d", sm.level2Cycles)
+		}
+		if !sm.alarmEnabled {
+			t.Error("expected alarm to remain enabled after silence")
+		}
+		if pub.fields["silenced-until"] == "" {
+			t.Error("expected silenced-until to be published")
+		}
+		sm.cleanupTimers()
+	}
+}
+
+func TestStateMachine_SilencedLevel2UsesHazardsOnly(t *testing.T) {
+	sm, _, _, _, alarm := createTestStateMachine()
+	ctx := context.Background()
+
+	sm.state = StateArmed
+	sm.alarmEnabled = true
+	sm.vehicleStandby = true
+
+	sm.SendEvent(SilenceEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if sm.State() != StateArmed {
+		t.Errorf("expected silence to keep StateArmed, got %s", sm.State())
+	}
+
+	sm.SendEvent(UnauthorizedSeatboxEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if sm.State() != StateTriggerLevel2 {
+		t.Fatalf("expected StateTriggerLevel2 while silenced, got %s", sm.State())
+	}
+	if !alarm.active || !alarm.hazardsOnly {
+		t.Error("expected hazards-only alarm while silenced")
+	}
+	sm.cleanupTimers()
+}
+
+func TestStateMachine_SilenceExpiresAndHornReturns(t *testing.T) {
+	sm, _, pub, _, alarm := createTestStateMachine()
+	ctx := context.Background()
+
+	sm.state = StateArmed
+	sm.alarmEnabled = true
+	sm.vehicleStandby = true
+
+	sm.SendEvent(SilenceEvent{Duration: 60})
+	sm.handleEvent(ctx, <-sm.events)
+
+	// Pretend the window elapsed.
+	sm.silencedUntil = time.Now().Add(-time.Second)
+	sm.SendEvent(SilenceExpiredTimerEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if !sm.silencedUntil.IsZero() {
+		t.Error("expected silence to be cleared")
+	}
+	if pub.fields["silenced-until"] != "" {
+		t.Errorf("expected silenced-until to be cleared, got %q", pub.fields["silenced-until"])
+	}
+
+	sm.SendEvent(UnauthorizedSeatboxEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if alarm.hazardsOnly {
+		t.Error("expected horn alarm after silence window expired")
+	}
+	sm.cleanupTimers()
+}
+
+func TestStateMachine_SilenceIgnoredWhenDisabled(t *testing.T) {
+	sm, _, _, _, _ := createTestStateMachine()
+	ctx := context.Background()
+
+	sm.state = StateWaitingEnabled
+
+	sm.SendEvent(SilenceEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if !sm.silencedUntil.IsZero() {
+		t.Error("expected silence to be ignored while alarm disabled")
+	}
+}
+
+func TestStateMachine_UserDisarmClearsSilence(t *testing.T) {
+	sm, _, _, _, _ := createTestStateMachine()
+	ctx := context.Background()
+
+	sm.state = StateArmed
+	sm.alarmEnabled = true
+	sm.vehicleStandby = true
+
+	sm.SendEvent(SilenceEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+
+	sm.SendEvent(VehicleStateChangedEvent{State: VehicleStateParked})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if !sm.silencedUntil.IsZero() {
+		t.Error("expected user disarm to clear silence")
+	}
+}
+
+func TestStateMachine_UncleanShutdownResumesLevel2(t *testing.T) {
+	sm, _, pub, inh, alarm := createTestStateMachine()
+	ctx := context.Background()
+
+	sm.alarmEnabled = true
+	sm.vehicleStandby = true
+
+	sm.SendEvent(UncleanShutdownEvent{Status: "level-2-triggered"})
+	sm.handleEvent(ctx, <-sm.events)
+	sm.SendEvent(InitCompleteEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if sm.State() != StateTriggerLevel2 {
+		t.Errorf("expected StateTriggerLevel2 after unclean shutdown mid-L2, got %s", sm.State())
+	}
+	if !alarm.active {
+		t.Error("expected alarm to resume")
+	}
+	if !inh.acquired {
+		t.Error("expected inhibitor to be acquired")
+	}
+	if pub.lastStatus != "level-2-triggered" {
+		t.Errorf("expected status 'level-2-triggered', got %s", pub.lastStatus)
+	}
+	sm.cleanupTimers()
+}
+
+func TestStateMachine_UncleanShutdownNoResumeWhenUnlocked(t *testing.T) {
+	sm, _, _, _, alarm := createTestStateMachine()
+	ctx := context.Background()
+
+	sm.alarmEnabled = true
+	sm.vehicleStandby = false
+
+	sm.SendEvent(UncleanShutdownEvent{Status: "level-2-triggered"})
+	sm.handleEvent(ctx, <-sm.events)
+	sm.SendEvent(InitCompleteEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if sm.State() != StateDisarmed {
+		t.Errorf("expected StateDisarmed, got %s", sm.State())
+	}
+	if alarm.active {
+		t.Error("expected alarm to stay off")
+	}
+}
+
+func TestStateMachine_UncleanShutdownOutsideLevel2Rearms(t *testing.T) {
+	sm, _, _, _, alarm := createTestStateMachine()
+	ctx := context.Background()
+
+	sm.alarmEnabled = true
+	sm.vehicleStandby = true
+
+	sm.SendEvent(UncleanShutdownEvent{Status: "manual-alarm"})
+	sm.handleEvent(ctx, <-sm.events)
+	sm.SendEvent(InitCompleteEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if sm.State() != StateArmed {
+		t.Errorf("expected StateArmed, got %s", sm.State())
+	}
+	if alarm.active {
+		t.Error("expected alarm to stay off")
+	}
+}
+
+func TestStateMachine_HornBudgetChanged(t *testing.T) {
+	sm, _, _, _, alarm := createTestStateMachine()
+	ctx := context.Background()
+
+	sm.state = StateArmed
+
+	sm.SendEvent(HornBudgetChangedEvent{MaxPerHour: 60})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if alarm.hornBudget != [3]time.Duration{60 * time.Second, 0, 0} {
+		t.Errorf("expected horn budget forwarded to controller, got %v", alarm.hornBudget)
+	}
+	if sm.State() != StateArmed {
+		t.Error("expected state to remain unchanged")
+	}
+}
+
+func TestStateMachine_HornNightChanged(t *testing.T) {
+	sm, _, _, _, alarm := createTestStateMachine()
+	ctx := context.Background()
+
+	sm.state = StateArmed
+
+	sm.SendEvent(HornNightChangedEvent{Start: 23 * 60, End: 6 * 60})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if alarm.hornNight != [2]int{23 * 60, 6 * 60} {
+		t.Errorf("expected horn night window forwarded to controller, got %v", alarm.hornNight)
+	}
+	if sm.State() != StateArmed {
+		t.Error("expected state to remain unchanged")
+	}
+}
+
+// allDayQuietHours covers every minute of the day so quiet-hours tests don't
+// depend on the wall clock.
+var allDayQuietHours = []QuietRange{{Start: 0, End: 12 * 60}, {Start: 12 * 60, End: 0}}
+
+func TestStateMachine_QuietHoursHazardsOnly(t *testing.T) {
+	sm, _, pub, _, alarm := createTestStateMachine()
+	ctx := context.Background()
+
+	sm.state = StateTriggerLevel1
+	sm.hornEnabled = true
+
+	sm.SendEvent(QuietHoursChangedEvent{Ranges: allDayQuietHours})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if pub.fields["audible-mode"] != "quiet-hazards" {
+		t.Errorf("expected audible-mode 'quiet-hazards', got %q", pub.fields["audible-mode"])
+	}
+
+	sm.SendEvent(BMXInterruptEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if !alarm.active || !alarm.hazardsOnly {
+		t.Error("expected hazards-only alarm during quiet hours")
+	}
+	sm.cleanupTimers()
+}
+
+func TestStateMachine_QuietHoursShortMode(t *testing.T) {
+	sm, _, pub, _, alarm := createTestStateMachine()
+	ctx := context.Background()
+
+	sm.state = StateTriggerLevel1
+	sm.hornEnabled = true
+	sm.alarmDuration = 10
+	sm.quietHours = allDayQuietHours
+
+	sm.SendEvent(QuietHoursModeChangedEvent{Mode: QuietModeShort})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if pub.fields["audible-mode"] != "quiet-short" {
+		t.Errorf("expected audible-mode 'quiet-short', got %q", pub.fields["audible-mode"])
+	}
+
+	sm.SendEvent(BMXInterruptEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if !alarm.active || alarm.hazardsOnly {
+		t.Error("expected horn alarm in quiet short mode")
+	}
+	if alarm.duration != quietShortDuration {
+		t.Errorf("expected shortened duration %v, got %v", quietShortDuration, alarm.duration)
+	}
+	sm.cleanupTimers()
+}
+
+func TestStateMachine_AudibleModeHornDisabled(t *testing.T) {
+	sm, _, pub, _, _ := createTestStateMachine()
+	ctx := context.Background()
+
+	sm.state = StateArmed
+	sm.quietHours = allDayQuietHours
+
+	sm.SendEvent(HornSettingChangedEvent{Enabled: false})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if pub.fields["audible-mode"] != "horn-disabled" {
+		t.Errorf("expected alarm.honk to take precedence, got %q", pub.fields["audible-mode"])
+	}
+}
+
+func TestStateMachine_ReadyAfterInit(t *testing.T) {
+	sm, _, _, _, _ := createTestStateMachine()
+	ctx := context.Background()
+
+	sm.SendEvent(AlarmModeChangedEvent{Enabled: true})
+	sm.handleEvent(ctx, <-sm.events)
+	select {
+	case <-sm.Ready():
+		t.Fatal("expected not ready while in init")
+	default:
+	}
+
+	sm.SendEvent(InitCompleteEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+	select {
+	case <-sm.Ready():
+	default:
+		t.Fatalf("expected ready after leaving init, state %s", sm.State())
+	}
+	sm.cleanupTimers()
+}
+
+func TestStateMachine_SilentModeSuppressesOutputs(t *testing.T) {
+	sm, _, pub, _, alarm := createTestStateMachine()
+	ctx := context.Background()
+	notifier := &mockNotifier{}
+	sm.SetNotifier(notifier)
+
+	sm.state = StateArmed
+	sm.alarmEnabled = true
+	sm.vehicleStandby = true
+	sm.hornEnabled = true
+
+	sm.SendEvent(SilentModeChangedEvent{Silent: true})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if pub.fields["audible-mode"] != "silent" {
+		t.Errorf("expected audible-mode 'silent', got %q", pub.fields["audible-mode"])
+	}
+
+	sm.SendEvent(BMXInterruptEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if sm.State() != StateTriggerLevel1Wait {
+		t.Fatalf("expected detection to run in silent mode, got %s", sm.State())
+	}
+	if alarm.blinkCalled != 0 || alarm.active {
+		t.Error("expected hazards and horn to be suppressed in silent mode")
+	}
+	if len(notifier.notifications) != 1 || notifier.notifications[0].Type != "level-1-triggered" {
+		t.Fatalf("expected one level-1 notification, got %+v", notifier.notifications)
+	}
+	if !notifier.notifications[0].Silent {
+		t.Error("expected notification to be marked silent")
+	}
+
+	sm.state = StateTriggerLevel1
+	sm.SendEvent(BMXInterruptEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if alarm.active {
+		t.Error("expected L2 alarm to be suppressed in silent mode")
+	}
+	if len(notifier.notifications) != 2 || notifier.notifications[1].Type != "level-2-triggered" {
+		t.Fatalf("expected level-2 notification, got %+v", notifier.notifications)
+	}
+	sm.cleanupTimers()
+}
+
+func TestStateMachine_NotifiesEpisode(t *testing.T) {
+	sm, _, _, _, alarm := createTestStateMachine()
+	ctx := context.Background()
+	notifier := &mockNotifier{}
+	sm.SetNotifier(notifier)
+
+	sm.state = StateDelayArmed
+	sm.alarmEnabled = true
+	sm.vehicleStandby = true
+
+	events := []Event{
+		DelayArmedTimerEvent{},
+		BMXInterruptEvent{},
+		Level1CooldownTimerEvent{},
+		BMXInterruptEvent{},
+		VehicleStateChangedEvent{State: VehicleStateParked},
+	}
+	for _, e := range events {
+		sm.SendEvent(e)
+		sm.handleEvent(ctx, <-sm.events)
+	}
+
+	if alarm.blinkCalled == 0 {
+		t.Error("expected hazards outside silent mode")
+	}
+
+	want := []string{
+		NotificationArmed,
+		NotificationLevel1,
+		NotificationLevel2,
+		NotificationDisarmedAfterAlarm,
+	}
+	if len(notifier.notifications) != len(want) {
+		t.Fatalf("expected %d notifications, got %+v", len(want), notifier.notifications)
+	}
+	for i, n := range notifier.notifications {
+		if n.Type != want[i] {
+			t.Errorf("notification %d: expected %q, got %q", i, want[i], n.Type)
+		}
+		if n.Silent {
+			t.Errorf("notification %d: expected not silent", i)
+		}
+	}
+	sm.cleanupTimers()
+}
+
+func TestStateMachine_NotifiesArmedOncePerLock(t *testing.T) {
+	sm, _, _, _, _ := createTestStateMachine()
+	ctx := context.Background()
+	notifier := &mockNotifier{}
+	sm.SetNotifier(notifier)
+
+	sm.state = StateDelayArmed
+	sm.alarmEnabled = true
+	sm.vehicleStandby = true
+
+	sm.SendEvent(DelayArmedTimerEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+
+	// L1 check elapses without movement: back to armed via delay_armed.
+	sm.state = StateDelayArmed
+	sm.SendEvent(DelayArmedTimerEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if len(notifier.notifications) != 1 {
+		t.Errorf("expected a single armed notification, got %+v", notifier.notifications)
+	}
+	sm.cleanupTimers()
+}
+
+func TestStateMachine_NotifiesSeatboxTamper(t *testing.T) {
+	sm, _, _, _, _ := createTestStateMachine()
+	ctx := context.Background()
+	notifier := &mockNotifier{}
+	sm.SetNotifier(notifier)
+
+	sm.state = StateArmed
+	sm.alarmEnabled = true
+	sm.vehicleStandby = true
+	sm.armedNotified = true
+
+	sm.SendEvent(UnauthorizedSeatboxEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if len(notifier.notifications) != 1 || notifier.notifications[0].Type != NotificationSeatboxTamper {
+		t.Errorf("expected seatbox-tamper notification, got %+v", notifier.notifications)
+	}
+	sm.cleanupTimers()
+}
+
+func TestStateMachine_NotifiesLevel2Exhausted(t *testing.T) {
+	sm, _, _, _, _ := createTestStateMachine()
+	ctx := context.Background()
+	notifier := &mockNotifier{}
+	sm.SetNotifier(notifier)
+
+	sm.state = StateWaitingMovement
+	sm.alarmEnabled = true
+	sm.vehicleStandby = true
+	sm.level2Cycles = maxLevel2Cycles - 1
+
+	sm.SendEvent(BMXInterruptEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if sm.State() != StateDisarmed {
+		t.Fatalf("expected StateDisarmed, got %s", sm.State())
+	}
+	if len(notifier.notifications) != 1 || notifier.notifications[0].Type != NotificationLevel2Exhausted {
+		t.Errorf("expected level-2-exhausted notification, got %+v", notifier.notifications)
+	}
+	sm.cleanupTimers()
+}
+
+func TestStateMachine_CapturesArmedAndTriggerPosition(t *testing.T) {
+	sm, _, pub, _, _ := createTestStateMachine()
+	ctx := context.Background()
+	notifier := &mockNotifier{}
+	sm.SetNotifier(notifier)
+
+	sm.state = StateDelayArmed
+	sm.alarmEnabled = true
+	sm.vehicleStandby = true
+	sm.geofenceRadius = 0
+
+	sm.SendEvent(GPSUpdateEvent{Position: Position{Latitude: 52.520008, Longitude: 13.404954, Fix: "3d"}})
+	sm.handleEvent(ctx, <-sm.events)
+	sm.SendEvent(DelayArmedTimerEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if pub.fields["armed-latitude"] != "52.520008" || pub.fields["armed-longitude"] != "13.404954" {
+		t.Errorf("expected armed position published, got %v", pub.fields)
+	}
+
+	sm.SendEvent(GPSUpdateEvent{Position: Position{Latitude: 52.521008, Longitude: 13.404954, Fix: "3d"}})
+	sm.handleEvent(ctx, <-sm.events)
+	sm.SendEvent(BMXInterruptEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if pub.fields["trigger-latitude"] != "52.521008" {
+		t.Errorf("expected trigger position published, got %q", pub.fields["trigger-latitude"])
+	}
+	if pub.fields["trigger-distance"] != "111" {
+		t.Errorf("expected trigger distance 111, got %q", pub.fields["trigger-distance"])
+	}
+
+	last := notifier.notifications[len(notifier.notifications)-1]
+	if last.Type != NotificationLevel1 || !last.HasDistance || math.Round(last.Distance) != 111 {
+		t.Errorf("expected level-1 notification with distance, got %+v", last)
+	}
+
+	sm.SendEvent(VehicleStateChangedEvent{State: VehicleStateParked})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if pub.fields["armed-latitude"] != "" {
+		t.Error("expected armed position cleared on disarm")
+	}
+	sm.cleanupTimers()
+}
+
+func TestStateMachine_ArmedPositionWaitsForFix(t *testing.T) {
+	sm, _, pub, _, _ := createTestStateMachine()
+	ctx := context.Background()
+
+	sm.state = StateArmed
+
+	sm.SendEvent(GPSUpdateEvent{Position: Position{Fix: "none"}})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if sm.armedPosition.Valid() {
+		t.Fatal("expected no armed position without a fix")
+	}
+
+	sm.SendEvent(GPSUpdateEvent{Position: Position{Latitude: 52.52, Longitude: 13.40, Fix: "2d"}})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if pub.fields["armed-fix"] != "2d" {
+		t.Errorf("expected armed position captured once a fix arrived, got %v", pub.fields)
+	}
+}
+
+func TestStateMachine_GeofenceBreachEscalatesAndTracks(t *testing.T) {
+	sm, _, pub, _, alarm := createTestStateMachine()
+	ctx := context.Background()
+	notifier := &mockNotifier{}
+	sm.SetNotifier(notifier)
+
+	sm.state = StateArmed
+	sm.alarmEnabled = true
+	sm.vehicleStandby = true
+	sm.armedPosition = Position{Latitude: 52.520008, Longitude: 13.404954, Fix: "3d"}
+
+	// 50 m: GPS jitter, stays armed.
+	sm.SendEvent(GPSUpdateEvent{Position: Position{Latitude: 52.520458, Longitude: 13.404954, Fix: "3d"}})
+	sm.handleEvent(ctx, <-sm.events)
+	if sm.tracking || len(sm.events) != 0 {
+		t.Fatal("expected no breach within the radius")
+	}
+
+	// ~222 m: breach.
+	sm.SendEvent(GPSUpdateEvent{Position: Position{Latitude: 52.522008, Longitude: 13.404954, Fix: "3d"}})
+	sm.handleEvent(ctx, <-sm.events)
+	if !sm.tracking || pub.fields["tracking"] != "true" {
+		t.Fatal("expected tracking to start on breach")
+	}
+	if pub.fields["tracking-latitude"] != "52.522008" {
+		t.Errorf("expected tracking position published, got %q", pub.fields["tracking-latitude"])
+	}
+
+	sm.handleEvent(ctx, <-sm.events)
+	if sm.State() != StateTriggerLevel2 {
+		t.Fatalf("expected escalation straight to L2, got %s", sm.State())
+	}
+	if !alarm.active {
+		t.Error("expected alarm to start")
+	}
+
+	types := map[string]bool{}
+	for _, n := range notifier.notifications {
+		types[n.Type] = true
+	}
+	for _, want := range []string{NotificationTracking, NotificationGeofenceBreach, NotificationLevel2} {
+		if !types[want] {
+			t.Errorf("expected %s notification, got %+v", want, notifier.notifications)
+		}
+	}
+
+	sm.SendEvent(TrackingTimerEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+	if n := notifier.notifications[len(notifier.notifications)-1]; n.Type != NotificationTracking {
+		t.Errorf("expected periodic tracking notification, got %+v", n)
+	}
+
+	sm.SendEvent(VehicleStateChangedEvent{State: VehicleStateParked})
+	sm.handleEvent(ctx, <-sm.events)
+	if sm.tracking || pub.fields["tracking"] != "false" {
+		t.Error("expected tracking to stop on disarm")
+	}
+	sm.cleanupTimers()
+}
+
+func TestStateMachine_GeofenceIgnoresInvalidFix(t *testing.T) {
+	sm, _, _, _, _ := createTestStateMachine()
+	ctx := context.Background()
+
+	sm.state = StateArmed
+	sm.armedPosition = Position{Latitude: 52.520008, Longitude: 13.404954, Fix: "3d"}
+
+	sm.SendEvent(GPSUpdateEvent{Position: Position{Latitude: 52.6, Longitude: 13.5, Fix: "none"}})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if sm.tracking || len(sm.events) != 0 {
+		t.Error("expected no breach without a valid fix")
+	}
+}
+
+func TestStateMachine_WheelTamperEscalates(t *testing.T) {
+	sm, _, _, _, alarm := createTestStateMachine()
+	ctx := context.Background()
+	notifier := &mockNotifier{}
+	sm.SetNotifier(notifier)
+
+	sm.state = StateArmed
+	sm.alarmEnabled = true
+	sm.vehicleStandby = true
+	sm.armedNotified = true
+
+	sm.SendEvent(WheelTamperEvent{Speed: 6})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if sm.State() != StateTriggerLevel1Wait {
+		t.Fatalf("expected StateTriggerLevel1Wait, got %s", sm.State())
+	}
+	if len(notifier.notifications) != 1 || notifier.notifications[0].Type != NotificationWheelTamper {
+		t.Errorf("expected wheel-tamper notification, got %+v", notifier.notifications)
+	}
+
+	sm.SendEvent(WheelTamperEvent{Speed: 6})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if sm.State() != StateTriggerLevel2 {
+		t.Fatalf("expected sustained wheel movement to escalate to L2, got %s", sm.State())
+	}
+	if !alarm.active {
+		t.Error("expected alarm to start")
+	}
+	sm.cleanupTimers()
+}
+
+func TestStateMachine_WheelTamperIgnoredWhenDisarmed(t *testing.T) {
+	sm, _, _, _, _ := createTestStateMachine()
+	ctx := context.Background()
+
+	sm.state = StateDisarmed
+	sm.alarmEnabled = true
+
+	sm.SendEvent(WheelTamperEvent{Speed: 20})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if sm.State() != StateDisarmed {
+		t.Errorf("expected StateDisarmed, got %s", sm.State())
+	}
+}
+
+func TestStateMachine_InputTamperLevels(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     State
+		level    TamperLevel
+		expected State
+	}{
+		{"armed L1", StateArmed, TamperLevelL1, StateTriggerLevel1Wait},
+		{"armed L2", StateArmed, TamperLevelL2, StateTriggerLevel2},
+		{"armed off", StateArmed, TamperLevelOff, StateArmed},
+		{"L1 wait L1", StateTriggerLevel1Wait, TamperLevelL1, StateTriggerLevel1Wait},
+		{"L1 wait L2", StateTriggerLevel1Wait, TamperLevelL2, StateTriggerLevel2},
+		{"L1 L1", StateTriggerLevel1, TamperLevelL1, StateTriggerLevel2},
+		{"disarmed", StateDisarmed, TamperLevelL2, StateDisarmed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm, _, _, _, _ := createTestStateMachine()
+			ctx := context.Background()
+
+			sm.state = tt.from
+			sm.alarmEnabled = true
+			sm.vehicleStandby = tt.from != StateDisarmed
+
+			sm.SendEvent(InputTamperEvent{Input: TamperInputKickstand, Level: tt.level})
+			sm.handleEvent(ctx, <-sm.events)
+
+			if sm.State() != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, sm.State())
+			}
+			sm.cleanupTimers()
+		})
+	}
+}
+
+func TestStateMachine_InputTamperNotification(t *testing.T) {
+	sm, _, _, _, _ := createTestStateMachine()
+	ctx := context.Background()
+	notifier := &mockNotifier{}
+	sm.SetNotifier(notifier)
+
+	sm.state = StateArmed
+	sm.alarmEnabled = true
+	sm.vehicleStandby = true
+
+	sm.SendEvent(InputTamperEvent{Input: TamperInputHandlebar, Level: TamperLevelL2})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if len(notifier.notifications) != 1 || notifier.notifications[0].Type != "handlebar-tamper" {
+		t.Errorf("expected handlebar-tamper notification, got %+v", notifier.notifications)
+	}
+	sm.cleanupTimers()
+}
+
+func TestParseTamperLevel(t *testing.T) {
+	for in, want := range map[string]TamperLevel{"off": TamperLevelOff, "l1": TamperLevelL1, "l2": TamperLevelL2} {
+		if got, ok := ParseTamperLevel(in); !ok || got != want {
+			t.Errorf("ParseTamperLevel(%q) = %v, %v", in, got, ok)
+		}
+	}
+	if _, ok := ParseTamperLevel("high"); ok {
+		t.Error("expected unknown level to be rejected")
+	}
+}
+
+func TestStateMachine_BatteryRemovedWhileArmed(t *testing.T) {
+	sm, _, _, _, alarm := createTestStateMachine()
+	ctx := context.Background()
+	notifier := &mockNotifier{}
+	sm.SetNotifier(notifier)
+
+	sm.state = StateArmed
+	sm.alarmEnabled = true
+	sm.vehicleStandby = true
+
+	sm.SendEvent(BatteryRemovedEvent{Battery: "battery:0"})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if sm.State() != StateTriggerLevel2 {
+		t.Fatalf("expected StateTriggerLevel2, got %s", sm.State())
+	}
+	if !alarm.active {
+		t.Error("expected alarm to start")
+	}
+	if len(notifier.notifications) != 1 || notifier.notifications[0].Type != NotificationBatteryTamper {
+		t.Errorf("expected battery-tamper notification, got %+v", notifier.notifications)
+	}
+	sm.cleanupTimers()
+}
+
+func TestStateMachine_BatterySwapDuringSeatboxAccess(t *testing.T) {
+	sm, _, _, _, alarm := createTestStateMachine()
+	ctx := context.Background()
+
+	sm.state = StateArmed
+	sm.alarmEnabled = true
+	sm.vehicleStandby = true
+
+	sm.SendEvent(SeatboxOpenedEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+	sm.SendEvent(BatteryRemovedEvent{Battery: "battery:0"})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if sm.State() != StateSeatboxAccess {
+		t.Errorf("expected authorized swap to stay in seatbox access, got %s", sm.State())
+	}
+	if alarm.active {
+		t.Error("expected no alarm for an authorized swap")
+	}
+	sm.cleanupTimers()
+}
+
+func TestStateMachine_AuthFailuresTriggerL1(t *testing.T) {
+	sm, _, pub, _, _ := createTestStateMachine()
+	ctx := context.Background()
+	notifier := &mockNotifier{}
+	sm.SetNotifier(notifier)
+
+	sm.state = StateArmed
+	sm.alarmEnabled = true
+	sm.vehicleStandby = true
+	sm.armedNotified = true
+	sm.authFailureThreshold = 3
+
+	for i := 0; i < 2; i++ {
+		sm.SendEvent(AuthFailureEvent{Source: "keycard"})
+		sm.handleEvent(ctx, <-sm.events)
+	}
+	if pub.fields["auth-failures"] != "2" || pub.fields["auth-failures-source"] != "keycard" {
+		t.Errorf("expected 2 keycard failures published, got %v", pub.fields)
+	}
+	if len(sm.events) != 0 {
+		t.Fatal("expected no escalation below the threshold")
+	}
+
+	sm.SendEvent(AuthFailureEvent{Source: "ble"})
+	sm.handleEvent(ctx, <-sm.events)
+	sm.handleEvent(ctx, <-sm.events)
+
+	if sm.State() != StateTriggerLevel1Wait {
+		t.Fatalf("expected StateTriggerLevel1Wait, got %s", sm.State())
+	}
+	if len(notifier.notifications) != 1 || notifier.notifications[0].Type != NotificationAuthFailures {
+		t.Errorf("expected auth-failures notification, got %+v", notifier.notifications)
+	}
+
+	sm.SendEvent(VehicleStateChangedEvent{State: VehicleStateParked})
+	sm.handleEvent(ctx, <-sm.events)
+	if pub.fields["auth-failures"] != "0" {
+		t.Errorf("expected failures cleared on disarm, got %q", pub.fields["auth-failures"])
+	}
+	sm.cleanupTimers()
+}
+
+func TestStateMachine_AuthFailuresWindow(t *testing.T) {
+	sm, _, _, _, _ := createTestStateMachine()
+	ctx := context.Background()
+
+	sm.state = StateArmed
+	sm.authFailureThreshold = 2
+	sm.authFailures = []time.Time{time.Now().Add(-2 * time.Minute)}
+
+	sm.SendEvent(AuthFailureEvent{Source: "keycard"})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if len(sm.events) != 0 {
+		t.Error("expected failures outside the window not to count")
+	}
+}
+
+func TestStateMachine_AuthFailuresIgnoredWhileDisarmed(t *testing.T) {
+	sm, _, pub, _, _ := createTestStateMachine()
+	ctx := context.Background()
+
+	sm.state = StateDisarmed
+	sm.alarmEnabled = true
+	sm.authFailureThreshold = 1
+
+	sm.SendEvent(AuthFailureEvent{Source: "keycard"})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if len(sm.authFailures) != 0 || pub.fields["auth-failures"] != "" {
+		t.Errorf("expected failures not counted while disarmed, got %d (%q)", len(sm.authFailures), pub.fields["auth-failures"])
+	}
+	if len(sm.events) != 0 || sm.State() != StateDisarmed {
+		t.Errorf("expected no escalation while disarmed, state %s", sm.State())
+	}
+}
+
+func TestStateMachine_AuthFailuresClearedOnArm(t *testing.T) {
+	sm, _, pub, _, _ := createTestStateMachine()
+	ctx := context.Background()
+
+	sm.state = StateArmed
+	sm.alarmEnabled = true
+	sm.vehicleStandby = true
+
+	sm.SendEvent(AuthFailureEvent{Source: "ble"})
+	sm.handleEvent(ctx, <-sm.events)
+	if pub.fields["auth-failures"] != "1" {
+		t.Fatalf("expected 1 failure counted while armed, got %q", pub.fields["auth-failures"])
+	}
+
+	// Left over from an episode that ended without the owner coming back.
+	sm.state = StateWaitingMovement
+	sm.SendEvent(Level2CheckTimerEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+	if sm.State() != StateDelayArmed {
+		t.Fatalf("expected StateDelayArmed, got %s", sm.State())
+	}
+	if len(sm.authFailures) != 0 || pub.fields["auth-failures"] != "0" {
+		t.Errorf("expected failures cleared on arm, got %d (%q)", len(sm.authFailures), pub.fields["auth-failures"])
+	}
+	sm.cleanupTimers()
+}
+
+func TestStateMachine_AuthFailuresLevel2(t *testing.T) {
+	sm, _, _, _, _ := createTestStateMachine()
+	ctx := context.Background()
+
+	sm.state = StateArmed
+	sm.alarmEnabled = true
+	sm.vehicleStandby = true
+	sm.authFailureThreshold = 1
+
+	sm.SendEvent(AuthFailureLevelChangedEvent{Level: TamperLevelL2})
+	sm.handleEvent(ctx, <-sm.events)
+	sm.SendEvent(AuthFailureEvent{Source: "ble"})
+	sm.handleEvent(ctx, <-sm.events)
+	sm.handleEvent(ctx, <-sm.events)
+
+	if sm.State() != StateTriggerLevel2 {
+		t.Errorf("expected StateTriggerLevel2, got %s", sm.State())
+	}
+	sm.cleanupTimers()
+}
+
+func TestStateMachine_KeycardTapDisarmsEpisode(t *testing.T) {
+	for _, from := range []State{StateTriggerLevel1Wait, StateTriggerLevel1, StateTriggerLevel2, StateWaitingMovement} {
+		t.Run(from.String(), func(t *testing.T) {
+			sm, _, pub, _, alarm := createTestStateMachine()
+			ctx := context.Background()
+			notifier := &mockNotifier{}
+			sm.SetNotifier(notifier)
+
+			sm.state = from
+			sm.alarmEnabled = true
+			sm.vehicleStandby = true
+			alarm.active = true
+
+			sm.SendEvent(KeycardAuthorizedEvent{UID: "04a1b2c3"})
+			sm.handleEvent(ctx, <-sm.events)
+
+			if sm.State() != StateDisarmed {
+				t.Fatalf("expected StateDisarmed, got %s", sm.State())
+			}
+			if alarm.active {
+				t.Error("expected alarm to be silenced")
+			}
+			if pub.fields["episode-disarm-uid"] != "04a1b2c3" || pub.fields["episode-disarmed-by"] != "keycard" {
+				t.Errorf("expected keycard uid in episode record, got %v", pub.fields)
+			}
+			n := notifier.notifications
+			if len(n) != 1 || n[0].Type != NotificationDisarmedAfterAlarm || n[0].Detail != "keycard:04a1b2c3" {
+				t.Errorf("expected disarmed-after-alarm with keycard detail, got %+v", n)
+			}
+			sm.cleanupTimers()
+		})
+	}
+}
+
+func TestStateMachine_KeycardTapIgnoredWhenArmed(t *testing.T) {
+	sm, _, pub, _, _ := createTestStateMachine()
+	ctx := context.Background()
+
+	sm.state = StateArmed
+	sm.alarmEnabled = true
+	sm.vehicleStandby = true
+
+	sm.SendEvent(KeycardAuthorizedEvent{UID: "04a1b2c3"})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if sm.State() != StateArmed {
+		t.Errorf("expected StateArmed, got %s", sm.State())
+	}
+	if _, ok := pub.fields["episode-disarm-uid"]; ok {
+		t.Error("expected no episode record outside an episode")
+	}
+}
+
+// Only L2 exhaustion starts the post-alarm cooldown; an owner disarming with
+// the keycard mid-episode must not be re-armed behind their back.
+func TestStateMachine_Level2ExhaustionStartsCooldown(t *testing.T) {
+	sm, _, _, _, _ := createTestStateMachine()
+	ctx := context.Background()
+
+	sm.state = StateWaitingMovement
+	sm.alarmEnabled = true
+	sm.vehicleStandby = true
+	sm.level2Cycles = maxLevel2Cycles - 1
+
+	sm.SendEvent(BMXInterruptEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+	if sm.State() != StateDisarmed {
+		t.Fatalf("expected StateDisarmed after L2 exhaustion, got %s", sm.State())
+	}
+	if _, ok := sm.timers["post_alarm_cooldown"]; !ok {
+		t.Error("expected post-alarm cooldown after L2 exhaustion")
+	}
+	sm.cleanupTimers()
+}
+
+func TestStateMachine_PresenceSuppressIgnoresMotion(t *testing.T) {
+	sm, _, pub, _, _ := createTestStateMachine()
+	ctx := context.Background()
+
+	sm.state = StateArmed
+	sm.alarmEnabled = true
+	sm.vehicleStandby = true
+
+	sm.SendEvent(PresenceChangedEvent{Device: "AA:BB:CC:DD:EE:FF", Mode: PresenceSuppress})
+	sm.handleEvent(ctx, <-sm.events)
+	if pub.fields["presence"] != "AA:BB:CC:DD:EE:FF" || pub.fields["presence-mode"] != "suppress" {
+		t.Errorf("expected presence published, got %v", pub.fields)
+	}
+
+	sm.SendEvent(BMXInterruptEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+	if sm.State() != StateArmed {
+		t.Fatalf("expected motion to be ignored while the owner is present, got %s", sm.State())
+	}
+
+	sm.SendEvent(PresenceChangedEvent{Mode: PresenceNone})
+	sm.handleEvent(ctx, <-sm.events)
+	sm.SendEvent(BMXInterruptEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+	if sm.State() != StateTriggerLevel1Wait {
+		t.Errorf("expected motion to trigger L1 once the owner left, got %s", sm.State())
+	}
+	sm.cleanupTimers()
+}
+
+func TestStateMachine_KeycardDisarmInStandbyNoCooldown(t *testing.T) {
+	sm, _, _, _, _ := createTestStateMachine()
+	ctx := context.Background()
+
+	sm.state = StateTriggerLevel2
+	sm.alarmEnabled = true
+	sm.vehicleStandby = true
+
+	sm.SendEvent(KeycardAuthorizedEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+	if sm.State() != StateDisarmed {
+		t.Fatalf("expected StateDisarmed after keycard, got %s", sm.State())
+	}
+	if _, ok := sm.timers["post_alarm_cooldown"]; ok {
+		t.Error("expected no post-alarm cooldown after a keycard disarm")
+	}
+	sm.cleanupTimers()
+}
+
+func TestStateMachine_PresenceDisarmAndRearm(t *testing.T) {
+	sm, _, _, _, _ := createTestStateMachine()
+	ctx := context.Background()
+
+	sm.state = StateArmed
+	sm.alarmEnabled = true
+	sm.vehicleStandby = true
+
+	sm.SendEvent(PresenceChangedEvent{Device: "AA:BB:CC:DD:EE:FF", Mode: PresenceDisarm})
+	sm.handleEvent(ctx, <-sm.events)
+	if sm.State() != StateDisarmed {
+		t.Fatalf("expected StateDisarmed, got %s", sm.State())
+	}
+	if _, ok := sm.timers["post_alarm_cooldown"]; ok {
+		t.Error("expected no post-alarm cooldown while the owner is present")
+	}
+
+	sm.SendEvent(PresenceChangedEvent{Mode: PresenceNone})
+	sm.handleEvent(ctx, <-sm.events)
+	if sm.State() != StateDelayArmed {
+		t.Errorf("expected re-arm once the device left, got %s", sm.State())
+	}
+	sm.cleanupTimers()
+}
+
+func TestStateMachine_PresenceDisarmKeepsDisarmedOnLock(t *testing.T) {
+	sm, _, _, _, _ := createTestStateMachine()
+	ctx := context.Background()
+
+	sm.state = StateDisarmed
+	sm.alarmEnabled = true
+	sm.presenceMode = PresenceDisarm
+
+	sm.SendEvent(VehicleStateChangedEvent{State: VehicleStateStandby})
+	sm.handleEvent(ctx, <-sm.events)
+	if sm.State() != StateDisarmed {
+		t.Fatalf("expected to stay disarmed while the owner is present, got %s", sm.State())
+	}
+
+	sm.SendEvent(PresenceChangedEvent{Mode: PresenceNone})
+	sm.handleEvent(ctx, <-sm.events)
+	if sm.State() != StateDelayArmed {
+		t.Errorf("expected re-arm once the device left, got %s", sm.State())
+	}
+	sm.cleanupTimers()
+}
+
+func TestStateMachine_TipOverNotifiesRegardlessOfState(t *testing.T) {
+	sm, _, pub, _, alarm := createTestStateMachine()
+	notifier := &mockNotifier{}
+	sm.SetNotifier(notifier)
+	ctx := context.Background()
+
+	sm.state = StateDisarmed
+
+	sm.SendEvent(TipOverEvent{TippedOver: true})
+	sm.handleEvent(ctx, <-sm.events)
+	if sm.State() != StateDisarmed {
+		t.Errorf("expected tip-over to leave the state alone, got %s", sm.State())
+	}
+	if pub.fields["tipped-over"] != "true" {
+		t.Errorf("expected tipped-over=true, got %q", pub.fields["tipped-over"])
+	}
+	if len(notifier.notifications) != 1 || notifier.notifications[0].Type != NotificationTippedOver {
+		t.Fatalf("expected one tipped-over notification, got %+v", notifier.notifications)
+	}
+	if alarm.tipOverBlinks != 0 {
+		t.Errorf("expected no blink without tipover-hazards, got %d", alarm.tipOverBlinks)
+	}
+
+	// Repeated reports while still on its side are not news.
+	sm.SendEvent(TipOverEvent{TippedOver: true})
+	sm.handleEvent(ctx, <-sm.events)
+	if len(notifier.notifications) != 1 {
+		t.Errorf("expected no repeat notification, got %d", len(notifier.notifications))
+	}
+
+	sm.SendEvent(TipOverEvent{TippedOver: false})
+	sm.handleEvent(ctx, <-sm.events)
+	if pub.fields["tipped-over"] != "false" {
+		t.Errorf("expected tipped-over cleared, got %q", pub.fields["tipped-over"])
+	}
+}
+
+func TestStateMachine_TipOverHazards(t *testing.T) {
+	sm, _, _, _, alarm := createTestStateMachine()
+	ctx := context.Background()
+
+	sm.state = StateArmed
+
+	sm.SendEvent(TipOverEvent{TippedOver: true, Hazards: true})
+	sm.handleEvent(ctx, <-sm.events)
+	if alarm.tipOverBlinks != 1 {
+		t.Errorf("expected tip-over blink, got %d", alarm.tipOverBlinks)
+	}
+	if alarm.blinkCalled != 0 {
+		t.Errorf("expected the tip-over pattern, not the L1 blink")
+	}
+
+	sm.SendEvent(TipOverEvent{TippedOver: false, Hazards: true})
+	sm.handleEvent(ctx, <-sm.events)
+	sm.silentMode = true
+	sm.SendEvent(TipOverEvent{TippedOver: true, Hazards: true})
+	sm.handleEvent(ctx, <-sm.events)
+	if alarm.tipOverBlinks != 1 {
+		t.Errorf("expected silent mode to suppress the blink, got %d", alarm.tipOverBlinks)
+	}
+}
+
+func TestStateMachine_TowSkipsL1AndTracks(t *testing.T) {
+	sm, _, pub, _, alarm := createTestStateMachine()
+	notifier := &mockNotifier{}
+	sm.SetNotifier(notifier)
+	ctx := context.Background()
+
+	sm.state = StateArmed
+	sm.alarmEnabled = true
+	sm.vehicleStandby = true
+
+	sm.SendEvent(TowDetectedEvent{Events: 8, Source: TowSourceEventCount})
+	sm.handleEvent(ctx, <-sm.events)
+	if sm.State() != StateTriggerLevel2 {
+		t.Fatalf("expected StateTriggerLevel2, got %s", sm.State())
+	}
+	if !alarm.active {
+		t.Error("expected alarm to be running")
+	}
+	if !sm.tracking || pub.fields["tracking"] != "true" {
+		t.Error("expected tracking to start on tow")
+	}
+	var types []string
+	for _, n := range notifier.notifications {
+		types = append(types, n.Type)
+	}
+	if !slices.Contains(types, NotificationTowDetected) {
+		t.Errorf("expected tow-detected notification, got %v", types)
+	}
+	sm.cleanupTimers()
+}
+
+func TestStateMachine_TowIgnored(t *testing.T) {
+	sm, _, _, _, _ := createTestStateMachine()
+	ctx := context.Background()
+
+	sm.state = StateDisarmed
+	sm.SendEvent(TowDetectedEvent{Source: TowSourceMotionService})
+	sm.handleEvent(ctx, <-sm.events)
+	if sm.State() != StateDisarmed || sm.tracking {
+		t.Errorf("expected tow to be ignored while disarmed, got %s tracking=%v", sm.State(), sm.tracking)
+	}
+
+	sm.state = StateArmed
+	sm.presenceMode = PresenceSuppress
+	sm.SendEvent(TowDetectedEvent{Source: TowSourceMotionService})
+	sm.handleEvent(ctx, <-sm.events)
+	if sm.State() != StateArmed || sm.tracking {
+		t.Errorf("expected tow to be ignored with a trusted device present, got %s tracking=%v", sm.State(), sm.tracking)
+	}
+	sm.cleanupTimers()
+}
+
+func TestStateMachine_LostModeEnterAndTrigger(t *testing.T) {
+	sm, _, pub, _, alarm := createTestStateMachine()
+	notifier := &mockNotifier{}
+	sm.SetNotifier(notifier)
+	ctx := context.Background()
+
+	sm.state = StateArmed
+	sm.alarmEnabled = true
+	sm.vehicleStandby = true
+
+	sm.SendEvent(LostModeEvent{Enabled: true})
+	sm.handleEvent(ctx, <-sm.events)
+	if sm.State() != StateLostMode {
+		t.Fatalf("expected StateLostMode, got %s", sm.State())
+	}
+	if pub.lastStatus != "armed" {
+		t.Errorf("expected status armed for motion-service, got %q", pub.lastStatus)
+	}
+	if pub.fields["lost-mode"] != "true" || pub.fields["lost-mode-since"] == "" {
+		t.Errorf("expected lost-mode published, got %v", pub.fields)
+	}
+	if !sm.tracking {
+		t.Error("expected tracking in lost mode")
+	}
+	if _, ok := sm.timers["lost_mode_beacon"]; !ok {
+		t.Error("expected beacon timer")
+	}
+	if len(notifier.notifications) == 0 || notifier.notifications[0].Type != NotificationLostMode ||
+		notifier.notifications[0].Detail != "enabled" {
+		t.Errorf("expected lost-mode enabled notification, got %+v", notifier.notifications)
+	}
+
+	sm.SendEvent(LostModeBeaconTimerEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+	if alarm.blinkCalled != 1 {
+		t.Errorf("expected hazard beacon, got %d blinks", alarm.blinkCalled)
+	}
+
+	// The owner's own card neither triggers nor leaves lost mode.
+	sm.SendEvent(KeycardAuthorizedEvent{UID: "04a1"})
+	sm.handleEvent(ctx, <-sm.events)
+	if sm.State() != StateLostMode {
+		t.Fatalf("expected authorized tap to be ignored, got %s", sm.State())
+	}
+
+	// An unlock goes straight to L2 instead of disarming.
+	sm.SendEvent(VehicleStateChangedEvent{State: VehicleStateParked})
+	sm.handleEvent(ctx, <-sm.events)
+	if sm.State() != StateTriggerLevel2 {
+		t.Fatalf("expected unlock to trigger L2, got %s", sm.State())
+	}
+
+	// Neither a keycard nor a runtime disarm ends it.
+	for _, event := range []Event{KeycardAuthorizedEvent{UID: "04a1"}, RuntimeDisarmEvent{}} {
+		sm.SendEvent(event)
+		sm.handleEvent(ctx, <-sm.events)
+		if sm.State() != StateTriggerLevel2 {
+			t.Fatalf("expected %s to be ignored, got %s", event.Type(), sm.State())
+		}
+	}
+	if !sm.tracking {
+		t.Error("expected tracking to keep running")
+	}
+
+	sm.SendEvent(Level2CheckTimerEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+	sm.SendEvent(Level2CheckTimerEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+	if sm.State() != StateLostMode {
+		t.Errorf("expected episode to return to lost mode, got %s", sm.State())
+	}
+
+	sm.SendEvent(AuthFailureEvent{Source: "keycard"})
+	sm.handleEvent(ctx, <-sm.events)
+	if sm.State() != StateTriggerLevel2 {
+		t.Errorf("expected failed unlock to trigger L2, got %s", sm.State())
+	}
+	sm.cleanupTimers()
+}
+
+func TestStateMachine_LostModeDisable(t *testing.T) {
+	sm, _, pub, _, alarm := createTestStateMachine()
+	ctx := context.Background()
+
+	sm.state = StateArmed
+	sm.alarmEnabled = true
+	sm.vehicleStandby = true
+
+	sm.SendEvent(LostModeEvent{Enabled: true})
+	sm.handleEvent(ctx, <-sm.events)
+	sm.SendEvent(BMXInterruptEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+	if sm.State() != StateTriggerLevel2 {
+		t.Fatalf("expected motion to trigger L2, got %s", sm.State())
+	}
+
+	sm.SendEvent(VehicleStateChangedEvent{State: VehicleStateParked})
+	sm.handleEvent(ctx, <-sm.events)
+	sm.SendEvent(LostModeEvent{Enabled: false})
+	sm.handleEvent(ctx, <-sm.events)
+	if sm.State() != StateDisarmed {
+		t.Fatalf("expected StateDisarmed after lifting lost mode while unlocked, got %s", sm.State())
+	}
+	if alarm.active {
+		t.Error("expected alarm stopped")
+	}
+	if sm.tracking || pub.fields["lost-mode"] != "false" {
+		t.Errorf("expected tracking stopped and lost-mode cleared, tracking=%v fields=%v", sm.tracking, pub.fields)
+	}
+	sm.cleanupTimers()
+}
+
+func TestStateMachine_LostModeRestoredOnInit(t *testing.T) {
+	sm, _, _, _, _ := createTestStateMachine()
+	notifier := &mockNotifier{}
+	sm.SetNotifier(notifier)
+	ctx := context.Background()
+
+	sm.SendEvent(LostModeEvent{Enabled: true, Restored: true})
+	sm.handleEvent(ctx, <-sm.events)
+	if sm.State() != StateInit {
+		t.Fatalf("expected to wait for init, got %s", sm.State())
+	}
+
+	sm.SendEvent(AlarmModeChangedEvent{Enabled: false})
+	sm.handleEvent(ctx, <-sm.events)
+	sm.SendEvent(InitCompleteEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+	if sm.State() != StateLostMode {
+		t.Fatalf("expected lost mode to survive the restart even with the alarm disabled, got %s", sm.State())
+	}
+	for _, n := range notifier.notifications {
+		if n.Type == NotificationLostMode {
+			t.Errorf("expected no lost-mode notification on restore")
+		}
+	}
+	sm.cleanupTimers()
+}
+
+func TestStateMachine_LostModeRefusesSilenceAndStop(t *testing.T) {
+	sm, _, pub, _, alarm := createTestStateMachine()
+	ctx := context.Background()
+
+	sm.state = StateArmed
+	sm.alarmEnabled = true
+	sm.vehicleStandby = true
+
+	sm.SendEvent(LostModeEvent{Enabled: true})
+	sm.handleEvent(ctx, <-sm.events)
+	sm.SendEvent(BMXInterruptEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+	if sm.State() != StateTriggerLevel2 || !alarm.active {
+		t.Fatalf("expected a sounding L2 episode, got %s (active %v)", sm.State(), alarm.active)
+	}
+
+	for _, event := range []Event{SilenceEvent{}, SilenceEvent{Duration: 600}, ManualStopEvent{}} {
+		sm.SendEvent(event)
+		sm.handleEvent(ctx, <-sm.events)
+		if sm.State() != StateTriggerLevel2 {
+			t.Fatalf("expected %s to be refused, got %s", event.Type(), sm.State())
+		}
+		if !alarm.active {
+			t.Fatalf("expected %s to leave the alarm sounding", event.Type())
+		}
+	}
+	if !sm.silencedUntil.IsZero() || pub.fields["silenced-until"] != "" {
+		t.Error("expected no snooze window in lost mode")
+	}
+	sm.cleanupTimers()
+}
+
+func TestStateMachine_LostModeRefusesManualStart(t *testing.T) {
+	sm, _, _, _, alarm := createTestStateMachine()
+	ctx := context.Background()
+
+	sm.state = StateArmed
+	sm.alarmEnabled = true
+	sm.vehicleStandby = true
+
+	sm.SendEvent(LostModeEvent{Enabled: true})
+	sm.handleEvent(ctx, <-sm.events)
+	if sm.State() != StateLostMode {
+		t.Fatalf("expected StateLostMode, got %s", sm.State())
+	}
+
+	sm.SendEvent(ManualTriggerEvent{Duration: 600})
+	sm.handleEvent(ctx, <-sm.events)
+	if sm.State() != StateLostMode {
+		t.Errorf("expected manual start to be refused in lost mode, got %s", sm.State())
+	}
+	if alarm.active {
+		t.Error("expected no manual alarm in lost mode")
+	}
+	if _, ok := sm.timers["lost_mode_beacon"]; !ok {
+		t.Error("expected the lost mode beacon to keep running")
+	}
+	sm.cleanupTimers()
+}
+
+func TestStateMachine_ImmobilizerBlocksUntilAuthorizedDisarm(t *testing.T) {
+	sm, _, pub, _, _ := createTestStateMachine()
+	immobilizer := &mockImmobilizer{}
+	sm.SetImmobilizer(immobilizer)
+	ctx := context.Background()
+
+	sm.state = StateArmed
+	sm.alarmEnabled = true
+	sm.vehicleStandby = true
+	sm.immobilizerEnabled = true
+
+	sm.SendEvent(UnauthorizedSeatboxEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+	if sm.State() != StateTriggerLevel2 {
+		t.Fatalf("expected StateTriggerLevel2, got %s", sm.State())
+	}
+	if !slices.Equal(immobilizer.commands, []bool{true}) || pub.fields["drive-blocked"] != "true" {
+		t.Fatalf("expected driving blocked, got %v %q", immobilizer.commands, pub.fields["drive-blocked"])
+	}
+
+	// The siren giving up and a (hot-wired) unlock don't lift the block.
+	sm.SendEvent(Level2CheckTimerEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+	sm.SendEvent(VehicleStateChangedEvent{State: VehicleStateReadyToDrive})
+	sm.handleEvent(ctx, <-sm.events)
+	if sm.State() != StateDisarmed {
+		t.Fatalf("expected StateDisarmed, got %s", sm.State())
+	}
+	if !sm.driveBlocked || len(immobilizer.commands) != 1 {
+		t.Fatalf("expected block to survive an unlock, got %v", immobilizer.commands)
+	}
+
+	// Nor do the unauthenticated disarm command, alarm.enabled=false and a
+	// (spoofable) trusted BLE device.
+	for _, event := range []Event{
+		RuntimeDisarmEvent{},
+		AlarmModeChangedEvent{Enabled: false},
+		PresenceChangedEvent{Device: "aa:bb:cc:dd:ee:ff", Mode: PresenceDisarm},
+	} {
+		sm.SendEvent(event)
+		sm.handleEvent(ctx, <-sm.events)
+		if !sm.driveBlocked || len(immobilizer.commands) != 1 {
+			t.Fatalf("expected %s not to release the block, got %v", event.Type(), immobilizer.commands)
+		}
+	}
+
+	sm.SendEvent(KeycardAuthorizedEvent{UID: "04a1"})
+	sm.handleEvent(ctx, <-sm.events)
+	if !slices.Equal(immobilizer.commands, []bool{true, false}) || pub.fields["drive-blocked"] != "false" {
+		t.Errorf("expected keycard to release the block, got %v %q", immobilizer.commands, pub.fields["drive-blocked"])
+	}
+	if pub.fields["drive-released-by"] != "keycard" {
+		t.Errorf("expected drive-released-by keycard, got %q", pub.fields["drive-released-by"])
+	}
+	sm.cleanupTimers()
+}
+
+func TestStateMachine_ImmobilizerOff(t *testing.T) {
+	sm, _, _, _, _ := createTestStateMachine()
+	immobilizer := &mockImmobilizer{}
+	sm.SetImmobilizer(immobilizer)
+	ctx := context.Background()
+
+	sm.state = StateArmed
+	sm.alarmEnabled = true
+	sm.vehicleStandby = true
+
+	sm.SendEvent(UnauthorizedSeatboxEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+	if len(immobilizer.commands) != 0 {
+		t.Errorf("expected no block with the immobilizer off, got %v", immobilizer.commands)
+	}
+	sm.cleanupTimers()
+}
+
+func TestStateMachine_ImmobilizerLostMode(t *testing.T) {
+	sm, _, _, _, _ := createTestStateMachine()
+	immobilizer := &mockImmobilizer{}
+	sm.SetImmobilizer(immobilizer)
+	ctx := context.Background()
+
+	sm.state = StateArmed
+	sm.alarmEnabled = true
+	sm.vehicleStandby = true
+	sm.immobilizerEnabled = true
+
+	sm.SendEvent(LostModeEvent{Enabled: true})
+	sm.handleEvent(ctx, <-sm.events)
+	if !sm.driveBlocked {
+		t.Fatal("expected lost mode to block driving")
+	}
+
+	// Only lifting lost mode releases it.
+	for _, event := range []Event{KeycardAuthorizedEvent{UID: "04a1"}, RuntimeDisarmEvent{}, ImmobilizerChangedEvent{Enabled: false}} {
+		sm.SendEvent(event)
+		sm.handleEvent(ctx, <-sm.events)
+		if !sm.driveBlocked {
+			t.Fatalf("expected %s not to release the block in lost mode", event.Type())
+		}
+	}
+
+	sm.SendEvent(LostModeEvent{Enabled: false})
+	sm.handleEvent(ctx, <-sm.events)
+	if sm.driveBlocked {
+		t.Error("expected lifting lost mode to release the block")
+	}
+	sm.cleanupTimers()
+}
+
+func TestStateMachine_DriveBlockRestored(t *testing.T) {
+	sm, _, _, _, _ := createTestStateMachine()
+	immobilizer := &mockImmobilizer{}
+	sm.SetImmobilizer(immobilizer)
+	ctx := context.Background()
+
+	sm.SendEvent(DriveBlockRestoredEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+	sm.SendEvent(ImmobilizerChangedEvent{Enabled: true})
+	sm.handleEvent(ctx, <-sm.events)
+	if len(immobilizer.commands) != 0 {
+		t.Fatalf("expected restore to wait for init, got %v", immobilizer.commands)
+	}
+	sm.SendEvent(InitCompleteEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+	if !sm.driveBlocked || !slices.Equal(immobilizer.commands, []bool{true}) {
+		t.Fatalf("expected block re-sent on restore, got %v", immobilizer.commands)
+	}
+
+	sm.SendEvent(KeycardAuthorizedEvent{UID: "04a1"})
+	sm.handleEvent(ctx, <-sm.events)
+	if sm.driveBlocked {
+		t.Error("expected keycard to release a restored block")
+	}
+}
+
+func TestStateMachine_DriveBlockRestoredImmobilizerOff(t *testing.T) {
+	sm, _, pub, _, _ := createTestStateMachine()
+	immobilizer := &mockImmobilizer{}
+	sm.SetImmobilizer(immobilizer)
+	ctx := context.Background()
+
+	// The setting was turned off while the service was down.
+	sm.SendEvent(DriveBlockRestoredEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+	sm.SendEvent(InitCompleteEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if sm.driveBlocked || !slices.Equal(immobilizer.commands, []bool{false}) {
+		t.Fatalf("expected persisted block cleared, got %v", immobilizer.commands)
+	}
+	if pub.fields["drive-blocked"] != "false" || pub.fields["drive-released-by"] != "setting" {
+		t.Errorf("expected drive-blocked cleared by setting, got %q %q",
+			pub.fields["drive-blocked"], pub.fields["drive-released-by"])
+	}
+	sm.cleanupTimers()
+}
+
+func TestStateMachine_UnattendedReminder(t *testing.T) {
+	sm, _, pub, _, alarm := createTestStateMachine()
+	notifier := &mockNotifier{}
+	sm.SetNotifier(notifier)
+	ctx := context.Background()
+
+	sm.state = StateDisarmed
+	sm.alarmEnabled = true
+	sm.unattendedHazards = true
+
+	sm.SendEvent(VehicleStateChangedEvent{State: VehicleStateParked})
+	sm.handleEvent(ctx, <-sm.events)
+	if _, ok := sm.timers["unattended"]; !ok {
+		t.Fatal("expected unattended timer while parked")
+	}
+
+	sm.SendEvent(UnattendedTimerEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+	if len(notifier.notifications) != 1 || notifier.notifications[0].Type != NotificationUnlockedUnattended {
+		t.Fatalf("expected unlocked-unattended notification, got %+v", notifier.notifications)
+	}
+	if pub.fields["unattended"] != "true" {
+		t.Errorf("expected unattended=true, got %q", pub.fields["unattended"])
+	}
+	if alarm.blinkCalled != 1 {
+		t.Errorf("expected hazard blink, got %d", alarm.blinkCalled)
+	}
+
+	// The rider coming back clears the reminder and starts over.
+	sm.SendEvent(RiderActivityEvent{Source: "brake:left"})
+	sm.handleEvent(ctx, <-sm.events)
+	if pub.fields["unattended"] != "false" {
+		t.Errorf("expected reminder cleared on activity, got %q", pub.fields["unattended"])
+	}
+	if _, ok := sm.timers["unattended"]; !ok {
+		t.Error("expected unattended timer restarted")
+	}
+
+	sm.SendEvent(VehicleStateChangedEvent{State: VehicleStateReadyToDrive})
+	sm.handleEvent(ctx, <-sm.events)
+	if _, ok := sm.timers["unattended"]; ok {
+		t.Error("expected unattended timer stopped once driving")
+	}
+	sm.cleanupTimers()
+}
+
+func TestStateMachine_UnattendedNeedsAlarmEnabled(t *testing.T) {
+	sm, _, _, _, _ := createTestStateMachine()
+	notifier := &mockNotifier{}
+	sm.SetNotifier(notifier)
+	ctx := context.Background()
+
+	sm.state = StateWaitingEnabled
+
+	sm.SendEvent(VehicleStateChangedEvent{State: VehicleStateParked})
+	sm.handleEvent(ctx, <-sm.events)
+	if _, ok := sm.timers["unattended"]; ok {
+		t.Error("expected no unattended timer with the alarm disabled")
+	}
+	sm.SendEvent(UnattendedTimerEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+	if len(notifier.notifications) != 0 {
+		t.Errorf("expected no reminder with the alarm disabled, got %+v", notifier.notifications)
+	}
+	sm.cleanupTimers()
+}
+
+func TestStateMachine_ArmingWarnsOnFailedPreconditions(t *testing.T) {
+	sm, _, pub, _, _ := createTestStateMachine()
+	ctx := context.Background()
+
+	sm.state = StateDisarmed
+	sm.alarmEnabled = true
+	sm.armingFaults[ArmingFaultSeatboxOpen] = true
+	sm.armingFaults[ArmingFaultMotionService] = true
+
+	sm.SendEvent(VehicleStateChangedEvent{State: VehicleStateStandby})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if sm.State() != StateDelayArmed {
+		t.Fatalf("expected StateDelayArmed, got %s", sm.State())
+	}
+	if got := pub.fields["arming-blocked"]; got != "seatbox-open,motion-service-unhealthy" {
+		t.Errorf("expected arming-blocked reasons, got %q", got)
+	}
+	if got := pub.fields["arming-blocked-action"]; got != "warn" {
+		t.Errorf("expected arming-blocked-action=warn, got %q", got)
+	}
+	if _, ok := sm.timers["delay_armed"]; !ok {
+		t.Error("expected delay_armed timer despite failed preconditions")
+	}
+
+	sm.SendEvent(VehicleStateChangedEvent{State: VehicleStateParked})
+	sm.handleEvent(ctx, <-sm.events)
+	if got := pub.fields["arming-blocked"]; got != "" {
+		t.Errorf("expected arming-blocked cleared on disarm, got %q", got)
+	}
+	sm.cleanupTimers()
+}
+
+func TestStateMachine_ArmingRefusedUntilPreconditionsClear(t *testing.T) {
+	sm, _, pub, inh, _ := createTestStateMachine()
+	ctx := context.Background()
+
+	sm.state = StateDisarmed
+	sm.alarmEnabled = true
+	sm.armingAction = ArmingActionRefuse
+
+	sm.SendEvent(ArmingFaultEvent{Fault: ArmingFaultKickstandUp, Active: true})
+	sm.handleEvent(ctx, <-sm.events)
+	sm.SendEvent(VehicleStateChangedEvent{State: VehicleStateStandby})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if sm.State() != StateDelayArmed {
+		t.Fatalf("expected StateDelayArmed, got %s", sm.State())
+	}
+	if got := pub.fields["arming-blocked"]; got != "kickstand-up" {
+		t.Errorf("expected arming-blocked=kickstand-up, got %q", got)
+	}
+	if got := pub.fields["arming-blocked-action"]; got != "refuse" {
+		t.Errorf("expected arming-blocked-action=refuse, got %q", got)
+	}
+	if _, ok := sm.timers["delay_armed"]; ok {
+		t.Error("expected no delay_armed timer while refused")
+	}
+	if inh.acquired {
+		t.Error("expected inhibitor released while refused")
+	}
+
+	sm.SendEvent(ArmingFaultEvent{Fault: ArmingFaultKickstandUp, Active: false})
+	sm.handleEvent(ctx, <-sm.events)
+	if got := pub.fields["arming-blocked"]; got != "" {
+		t.Errorf("expected arming-blocked cleared, got %q", got)
+	}
+	if _, ok := sm.timers["delay_armed"]; !ok {
+		t.Fatal("expected delay_armed timer once preconditions cleared")
+	}
+	if !inh.acquired {
+		t.Error("expected inhibitor re-acquired for arming")
+	}
+
+	sm.SendEvent(DelayArmedTimerEvent{})
+	sm.handleEvent(ctx, <-sm.events)
+	if sm.State() != StateArmed {
+		t.Errorf("expected StateArmed, got %s", sm.State())
+	}
+	sm.cleanupTimers()
+}
+
+func TestStateMachine_ArmingPreconditionsOff(t *testing.T) {
+	sm, _, pub, _, _ := createTestStateMachine()
+	ctx := context.Background()
+
+	sm.state = StateDisarmed
+	sm.alarmEnabled = true
+	sm.armingAction = ArmingActionOff
+	sm.armingFaults[ArmingFaultSeatboxOpen] = true
+
+	sm.SendEvent(VehicleStateChangedEvent{State: VehicleStateStandby})
+	sm.handleEvent(ctx, <-sm.events)
+
+	if _, ok := pub.fields["arming-blocked"]; ok {
+		t.Errorf("expected no arming-blocked with checks off, got %q", pub.fields["arming-blocked"])
+	}
+	if _, ok := sm.timers["delay_armed"]; !ok {
+		t.Error("expected delay_armed timer")
+	}
+	sm.cleanupTimers()
+}
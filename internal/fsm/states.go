@@ -19,12 +19,23 @@ func (sm *StateMachine) onEnterInit(ctx context.Context) {
 	sm.log.Info("entering init state")
 }
 
+// onExitInit handles exit from init state.
+func (sm *StateMachine) onExitInit(ctx context.Context) {
+	sm.applyRestoredDriveBlock()
+	sm.readyOnce.Do(func() { close(sm.ready) })
+}
+
 // onEnterWaitingEnabled handles entry to waiting_enabled state.
 func (sm *StateMachine) onEnterWaitingEnabled(ctx context.Context) {
 	sm.log.Info("entering waiting_enabled state")
 	sm.inhibitor.Release()
 	sm.level2Cycles = 0
 	sm.wakeFromHibernation = false
+	sm.clearSilence()
+	sm.stopTracking()
+	sm.clearArmedPosition()
+	sm.clearAuthFailures()
+	sm.publishArmingBlocked("")
 }
 
 // onEnterDisarmed handles entry to disarmed state.
@@ -32,18 +43,32 @@ func (sm *StateMachine) onEnterDisarmed(ctx context.Context) {
 	sm.log.Info("entering disarmed state")
 	sm.inhibitor.Release()
 	sm.level2Cycles = 0
+	exhausted := sm.level2Exhausted
+	sm.level2Exhausted = false
+	sm.publishArmingBlocked("")
+
+	// Disarmed by a trusted BLE device: re-arming waits for it to leave.
+	if sm.presenceDisarmed {
+		sm.log.Info("disarmed by trusted device, waiting for it to leave")
+		return
+	}
 
-	// If we got here with the vehicle still in stand-by, this is the L2-exhaustion
-	// path: the alarm gave up after a long blare. Start a quiet window before
-	// re-arming (or handing back to nRF52 hibernation), so a stuck/false trigger
-	// can't blare all night and a thief can't simply wait it out.
-	if sm.vehicleStandby && sm.alarmEnabled {
+	// L2 exhaustion: the alarm gave up after a long blare. Start a quiet
+	// window before re-arming (or handing back to nRF52 hibernation), so a
+	// stuck/false trigger can't blare all night and a thief can't simply wait
+	// it out. Any other disarm in stand-by (keycard, runtime disarm) stays
+	// disarmed.
+	if exhausted && sm.vehicleStandby && sm.alarmEnabled {
 		sm.log.Info("post-alarm cooldown started", "duration", "5m", "wake_from_hibernation", sm.wakeFromHibernation)
 		sm.startTimer("post_alarm_cooldown", 5*time.Minute, func() {
 			sm.SendEvent(PostAlarmCooldownTimerEvent{})
 		})
 	} else {
 		sm.wakeFromHibernation = false
+		sm.clearSilence()
+		sm.stopTracking()
+		sm.clearArmedPosition()
+		sm.clearAuthFailures()
 	}
 }
 
@@ -60,17 +85,17 @@ func (sm *StateMachine) onEnterDelayArmed(ctx context.Context) {
 		sm.log.Error("failed to acquire inhibitor", "error", err)
 	}
 
-	sm.startTimer("delay_armed", 5*time.Second, func() {
-		sm.SendEvent(DelayArmedTimerEvent{})
-	})
-
 	sm.level2Cycles = 0
 	sm.requestDisarm = false
+	sm.armingRefused = false
+	sm.clearAuthFailures()
+	sm.checkArming(true)
 }
 
 // onExitDelayArmed handles exit from delay_armed state.
 func (sm *StateMachine) onExitDelayArmed(ctx context.Context) {
 	sm.stopTimer("delay_armed")
+	sm.armingRefused = false
 }
 
 // onEnterArmed handles entry to armed state.
@@ -78,6 +103,7 @@ func (sm *StateMachine) onEnterArmed(ctx context.Context) {
 	sm.log.Info("entering armed state", "hibernation_imminent", sm.hibernationImminent)
 
 	sm.inhibitor.Release()
+	sm.captureArmedPosition()
 
 	// If pm-service already signalled hibernation-imminent before we got
 	// here, perform the synchronous prepare-hibernation handshake now —
@@ -110,10 +136,10 @@ func (sm *StateMachine) onEnterTriggerLevel1Wait(ctx context.Context) {
 		sm.log.Error("failed to acquire inhibitor", "error", err)
 	}
 
+	sm.captureTriggerPosition()
+
 	// Blink hazards once when L1 is first triggered.
-	if err := sm.alarmController.BlinkHazards(); err != nil {
-		sm.log.Error("failed to blink hazards", "error", err)
-	}
+	sm.blinkHazards()
 
 	// Skip the hair trigger when we just came up from a hibernation-wake
 	// motion edge — that initial edge is the wake event, not a tampering.
@@ -121,7 +147,7 @@ func (sm *StateMachine) onEnterTriggerLevel1Wait(ctx context.Context) {
 		sm.log.Info("skipping hair trigger on hibernation-wake edge")
 	} else if sm.hairTriggerEnabled {
 		sm.log.Info("hair trigger active, starting short alarm", "duration", sm.hairTriggerDuration)
-		sm.alarmController.Start(time.Duration(sm.hairTriggerDuration) * time.Second)
+		sm.startAlarm(time.Duration(sm.hairTriggerDuration) * time.Second)
 	}
 
 	sm.startTimer("level1_cooldown", time.Duration(sm.l1CooldownDuration)*time.Second, func() {
@@ -157,7 +183,10 @@ func (sm *StateMachine) onEnterTriggerLevel2(ctx context.Context) {
 		sm.log.Error("failed to acquire inhibitor", "error", err)
 	}
 
-	sm.alarmController.Start(time.Duration(sm.alarmDuration) * time.Second)
+	sm.captureTriggerPosition()
+	sm.blockDrive()
+
+	sm.startAlarm(time.Duration(sm.alarmDuration) * time.Second)
 
 	sm.startTimer("level2_check", 50*time.Second, func() {
 		sm.SendEvent(Level2CheckTimerEvent{})
@@ -174,7 +203,7 @@ func (sm *StateMachine) onExitTriggerLevel2(ctx context.Context) {
 func (sm *StateMachine) onEnterWaitingMovement(ctx context.Context) {
 	sm.log.Info("entering waiting_movement state", "duration", "50s", "cycle", sm.level2Cycles)
 
-	sm.alarmController.Start(time.Duration(sm.alarmDuration) * time.Second)
+	sm.startAlarm(time.Duration(sm.alarmDuration) * time.Second)
 
 	sm.startTimer("waiting_movement", 50*time.Second, func() {
 		sm.SendEvent(Level2CheckTimerEvent{})
@@ -202,3 +231,48 @@ func (sm *StateMachine) onExitSeatboxAccess(ctx context.Context) {
 	sm.log.Info("exiting seatbox_access state")
 	sm.inhibitor.Release()
 }
+
+// onEnterManualAlarm handles entry to manual_alarm state.
+func (sm *StateMachine) onEnterManualAlarm(ctx context.Context) {
+	sm.log.Info("entering manual_alarm state", "duration", sm.manualDuration)
+
+	if err := sm.inhibitor.Acquire("Manual alarm"); err != nil {
+		sm.log.Error("failed to acquire inhibitor", "error", err)
+	}
+
+	duration := time.Duration(sm.manualDuration) * time.Second
+	sm.startAlarm(duration)
+
+	sm.startTimer("manual_alarm", duration, func() {
+		sm.SendEvent(ManualAlarmTimerEvent{})
+	})
+}
+
+// onExitManualAlarm handles exit from manual_alarm state.
+func (sm *StateMachine) onExitManualAlarm(ctx context.Context) {
+	sm.stopTimer("manual_alarm")
+	sm.alarmController.Stop()
+}
+
+// onEnterLostMode handles entry to lost_mode state.
+func (sm *StateMachine) onEnterLostMode(ctx context.Context) {
+	sm.log.Info("entering lost_mode state", "tracking_interval", sm.lostModeInterval, "beacon", sm.lostModeBeacon)
+
+	// Stay awake: a stolen scooter that hibernates stops reporting.
+	if err := sm.inhibitor.Acquire("Lost mode"); err != nil {
+		sm.log.Error("failed to acquire inhibitor", "error", err)
+	}
+
+	sm.level2Cycles = 0
+	sm.captureArmedPosition()
+	sm.blockDrive()
+	if !sm.tracking {
+		sm.startTracking()
+	}
+	sm.scheduleLostModeBeacon()
+}
+
+// onExitLostMode handles exit from lost_mode state.
+func (sm *StateMachine) onExitLostMode(ctx context.Context) {
+	sm.stopTimer("lost_mode_beacon")
+}
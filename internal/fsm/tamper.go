@@ -0,0 +1,80 @@
+package fsm
+
+// TamperInput names a vehicle input whose change while locked counts as
+// tamper.
+type TamperInput string
+
+const (
+	TamperInputKickstand TamperInput = "kickstand"
+	TamperInputHandlebar TamperInput = "handlebar"
+	TamperInputBrake     TamperInput = "brake"
+)
+
+// TamperLevel is the alarm level a tamper input escalates to.
+type TamperLevel int
+
+const (
+	// TamperLevelOff ignores the input.
+	TamperLevelOff TamperLevel = iota
+	// TamperLevelL1 treats the input like motion: armed → L1, L1 → L2.
+	TamperLevelL1
+	// TamperLevelL2 goes straight to L2.
+	TamperLevelL2
+)
+
+func (l TamperLevel) String() string {
+	switch l {
+	case TamperLevelL1:
+		return "l1"
+	case TamperLevelL2:
+		return "l2"
+	default:
+		return "off"
+	}
+}
+
+// ParseTamperLevel parses "off", "l1" or "l2". ok is false for anything else.
+func ParseTamperLevel(s string) (level TamperLevel, ok bool) {
+	switch s {
+	case "off", "false":
+		return TamperLevelOff, true
+	case "l1":
+		return TamperLevelL1, true
+	case "l2":
+		return TamperLevelL2, true
+	}
+	return TamperLevelOff, false
+}
+
+// tamperNotification returns the notification type for a tamper event.
+func tamperNotification(event Event) (string, bool) {
+	switch e := event.(type) {
+	case UnauthorizedSeatboxEvent:
+		return NotificationSeatboxTamper, true
+	case WheelTamperEvent:
+		return NotificationWheelTamper, true
+	case InputTamperEvent:
+		return string(e.Input) + "-tamper", true
+	case BatteryRemovedEvent:
+		return NotificationBatteryTamper, true
+	case AuthFailureThresholdEvent:
+		return NotificationAuthFailures, true
+	case TowDetectedEvent:
+		return NotificationTowDetected, true
+	}
+	return "", false
+}
+
+// tamperLevel returns the configured level of a level-mapped tamper event.
+// ok is false for other events and for inputs that are switched off.
+func tamperLevel(event Event) (level TamperLevel, ok bool) {
+	switch e := event.(type) {
+	case InputTamperEvent:
+		level = e.Level
+	case AuthFailureThresholdEvent:
+		level = e.Level
+	default:
+		return TamperLevelOff, false
+	}
+	return level, level != TamperLevelOff
+}
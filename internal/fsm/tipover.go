@@ -0,0 +1,40 @@
+package fsm
+
+import (
+	"strconv"
+	"time"
+)
+
+// setTippedOver records a tip-over or the scooter standing upright again.
+// Tipping over raises a notification and, if asked for, the tip-over hazard
+// pattern regardless of the alarm state; standing up clears the condition.
+func (sm *StateMachine) setTippedOver(tippedOver, hazards bool) {
+	if sm.tippedOver == tippedOver {
+		return
+	}
+	sm.tippedOver = tippedOver
+
+	if !tippedOver {
+		sm.log.Info("scooter upright again")
+		sm.publishFields(map[string]string{"tipped-over": "false"})
+		return
+	}
+
+	sm.log.Info("scooter tipped over", "state", sm.state.String())
+	sm.publishFields(map[string]string{
+		"tipped-over":    "true",
+		"tipped-over-at": strconv.FormatInt(time.Now().Unix(), 10),
+	})
+	sm.notify(NotificationTippedOver)
+
+	if !hazards {
+		return
+	}
+	if sm.silentMode {
+		sm.log.Info("silent mode, suppressing tip-over blink")
+		return
+	}
+	if err := sm.alarmController.BlinkTipOver(); err != nil {
+		sm.log.Error("failed to blink tip-over hazards", "error", err)
+	}
+}
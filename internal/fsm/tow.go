@@ -0,0 +1,28 @@
+package fsm
+
+// towApplies reports whether a sustained motion episode counts against the
+// scooter in the current state: whenever it is armed or in an episode,
+// unless a trusted device is suppressing motion triggers.
+func (sm *StateMachine) towApplies() bool {
+	if sm.presenceMode == PresenceSuppress {
+		return false
+	}
+	switch sm.state {
+	case StateArmed, StateTriggerLevel1Wait, StateTriggerLevel1, StateTriggerLevel2, StateWaitingMovement:
+		return true
+	}
+	return false
+}
+
+// onTowDetected starts following a lifted or towed scooter right away rather
+// than waiting for it to clear the geofence. The escalation to L2 is up to
+// the transition.
+func (sm *StateMachine) onTowDetected(e TowDetectedEvent) {
+	if !sm.towApplies() {
+		return
+	}
+	sm.log.Warn("tow detected", "source", e.Source, "events", e.Events, "state", sm.state.String())
+	if !sm.tracking {
+		sm.startTracking()
+	}
+}
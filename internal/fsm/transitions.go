@@ -1,6 +1,10 @@
 package fsm
 
-import "context"
+import (
+	"context"
+	"strconv"
+	"time"
+)
 
 // shouldDisarmForVehicleState returns true if the vehicle state should cause the alarm to disarm.
 // The alarm stays armed for all states except explicit "user unlocked" states.
@@ -13,6 +17,32 @@ func shouldDisarmForVehicleState(state VehicleState) bool {
 	}
 }
 
+// isManualAlarmTrigger reports whether an event is a real alarm trigger that
+// takes over from a running manual alarm.
+func isManualAlarmTrigger(event Event) bool {
+	switch event.(type) {
+	case BMXInterruptEvent, TowDetectedEvent, WheelTamperEvent, BatteryRemovedEvent,
+		UnauthorizedSeatboxEvent, GeofenceBreachEvent:
+		return true
+	}
+	_, ok := tamperLevel(event)
+	return ok
+}
+
+// keycardDisarm ends a running episode on an authorized keycard tap: the
+// owner gets it silenced and disarmed without having to unlock.
+func (sm *StateMachine) keycardDisarm(e KeycardAuthorizedEvent) State {
+	sm.log.Info("authorized keycard during alarm, disarming", "uid", e.UID, "state", sm.state.String())
+	sm.alarmController.Stop()
+	sm.stopTracking()
+	sm.publishFields(map[string]string{
+		"episode-disarmed-by": "keycard",
+		"episode-disarm-uid":  e.UID,
+		"episode-disarmed-at": strconv.FormatInt(time.Now().Unix(), 10),
+	})
+	return StateDisarmed
+}
+
 // getTransition determines the next state based on current state and event
 func (sm *StateMachine) getTransition(event Event) State {
 	switch sm.state {
@@ -26,9 +56,21 @@ func (sm *StateMachine) getTransition(event Event) State {
 		if be, ok := event.(BMXInterruptEvent); ok && be.Data == "wake-hibernation" {
 			sm.wakeFromHibernation = true
 		}
+		if e, ok := event.(UncleanShutdownEvent); ok && e.Status == "level-2-triggered" {
+			sm.resumeLevel2 = true
+		}
 		if _, ok := event.(InitCompleteEvent); ok {
+			resumeLevel2 := sm.resumeLevel2
+			sm.resumeLevel2 = false
 			if sm.alarmEnabled {
 				if sm.vehicleStandby {
+					// The previous instance died mid-siren and the scooter is
+					// still locked: pick the episode back up instead of
+					// silently re-arming.
+					if resumeLevel2 {
+						sm.log.Info("init after unclean shutdown in L2, resuming alarm")
+						return StateTriggerLevel2
+					}
 					// If motion-service stamped wake-hibernation onto our event
 					// stream during init (either via the durable motion.wake-cause
 					// hash field or the live motion:interrupt pub/sub), drop
@@ -38,6 +80,10 @@ func (sm *StateMachine) getTransition(event Event) State {
 						sm.log.Info("init wake-from-hibernation, triggering L1")
 						return StateTriggerLevel1Wait
 					}
+					if sm.presenceMode == PresenceDisarm {
+						sm.presenceDisarmed = true
+						return StateDisarmed
+					}
 					return StateArmed
 				}
 				return StateDisarmed
@@ -61,6 +107,19 @@ func (sm *StateMachine) getTransition(event Event) State {
 		}
 
 	case StateDisarmed:
+		if e, ok := event.(PresenceChangedEvent); ok && e.Mode != PresenceDisarm && sm.presenceDisarmed {
+			sm.presenceDisarmed = false
+			if sm.alarmEnabled && sm.vehicleStandby {
+				sm.log.Info("trusted device left, re-arming")
+				return StateDelayArmed
+			}
+		}
+		if e, ok := event.(VehicleStateChangedEvent); ok && e.State == VehicleStateStandby && sm.presenceMode == PresenceDisarm {
+			sm.vehicleStandby = true
+			sm.presenceDisarmed = true
+			sm.log.Info("locked with trusted device connected, staying disarmed")
+			return sm.state
+		}
 		if e, ok := event.(VehicleStateChangedEvent); ok && e.State == VehicleStateStandby {
 			sm.vehicleStandby = true
 			return StateDelayArmed
@@ -77,6 +136,13 @@ func (sm *StateMachine) getTransition(event Event) State {
 		}
 
 	case StateDelayArmed:
+		if e, ok := event.(PresenceChangedEvent); ok && e.Mode == PresenceDisarm {
+			sm.presenceDisarmed = true
+			return StateDisarmed
+		}
+		if _, ok := event.(BatteryRemovedEvent); ok {
+			return StateTriggerLevel2
+		}
 		if _, ok := event.(DelayArmedTimerEvent); ok {
 			return StateArmed
 		}
@@ -96,6 +162,31 @@ func (sm *StateMachine) getTransition(event Event) State {
 		}
 
 	case StateArmed:
+		if e, ok := event.(PresenceChangedEvent); ok && e.Mode == PresenceDisarm {
+			sm.presenceDisarmed = true
+			return StateDisarmed
+		}
+		if _, ok := event.(BatteryRemovedEvent); ok {
+			return StateTriggerLevel2
+		}
+		// Somebody is pushing or towing the scooter: warn first, escalate
+		// if the wheel keeps turning.
+		if _, ok := event.(WheelTamperEvent); ok {
+			return StateTriggerLevel1Wait
+		}
+		if level, ok := tamperLevel(event); ok {
+			if level == TamperLevelL2 {
+				return StateTriggerLevel2
+			}
+			return StateTriggerLevel1Wait
+		}
+		if _, ok := event.(GeofenceBreachEvent); ok {
+			return StateTriggerLevel2
+		}
+		// Lifted or towed: no point in the gentle L1 warning.
+		if _, ok := event.(TowDetectedEvent); ok && sm.towApplies() {
+			return StateTriggerLevel2
+		}
 		if _, ok := event.(SeatboxOpenedEvent); ok {
 			sm.preSeatboxState = StateArmed
 			return StateSeatboxAccess
@@ -107,6 +198,10 @@ func (sm *StateMachine) getTransition(event Event) State {
 			if be.Data == "wake-hibernation" {
 				sm.wakeFromHibernation = true
 			}
+			if sm.presenceMode == PresenceSuppress {
+				sm.log.Info("motion ignored, trusted device connected")
+				return sm.state
+			}
 			return StateTriggerLevel1Wait
 		}
 		if e, ok := event.(VehicleStateChangedEvent); ok && shouldDisarmForVehicleState(e.State) {
@@ -117,14 +212,29 @@ func (sm *StateMachine) getTransition(event Event) State {
 			sm.alarmEnabled = false
 			return StateWaitingEnabled
 		}
-		if _, ok := event.(ManualTriggerEvent); ok {
-			return StateTriggerLevel2
-		}
 		if _, ok := event.(RuntimeDisarmEvent); ok {
 			return StateDisarmed
 		}
 
 	case StateTriggerLevel1Wait:
+		if e, ok := event.(KeycardAuthorizedEvent); ok {
+			return sm.keycardDisarm(e)
+		}
+		if _, ok := event.(BatteryRemovedEvent); ok {
+			return StateTriggerLevel2
+		}
+		if _, ok := event.(WheelTamperEvent); ok {
+			return StateTriggerLevel2
+		}
+		if level, ok := tamperLevel(event); ok && level == TamperLevelL2 {
+			return StateTriggerLevel2
+		}
+		if _, ok := event.(GeofenceBreachEvent); ok {
+			return StateTriggerLevel2
+		}
+		if _, ok := event.(TowDetectedEvent); ok && sm.towApplies() {
+			return StateTriggerLevel2
+		}
 		if _, ok := event.(SeatboxOpenedEvent); ok {
 			sm.preSeatboxState = StateTriggerLevel1Wait
 			return StateSeatboxAccess
@@ -148,6 +258,24 @@ func (sm *StateMachine) getTransition(event Event) State {
 		}
 
 	case StateTriggerLevel1:
+		if e, ok := event.(KeycardAuthorizedEvent); ok {
+			return sm.keycardDisarm(e)
+		}
+		if _, ok := event.(BatteryRemovedEvent); ok {
+			return StateTriggerLevel2
+		}
+		if _, ok := event.(WheelTamperEvent); ok {
+			return StateTriggerLevel2
+		}
+		if _, ok := tamperLevel(event); ok {
+			return StateTriggerLevel2
+		}
+		if _, ok := event.(GeofenceBreachEvent); ok {
+			return StateTriggerLevel2
+		}
+		if _, ok := event.(TowDetectedEvent); ok && sm.towApplies() {
+			return StateTriggerLevel2
+		}
 		if _, ok := event.(SeatboxOpenedEvent); ok {
 			sm.preSeatboxState = StateTriggerLevel1
 			return StateSeatboxAccess
@@ -174,8 +302,12 @@ func (sm *StateMachine) getTransition(event Event) State {
 		}
 
 	case StateTriggerLevel2:
+		if e, ok := event.(KeycardAuthorizedEvent); ok {
+			return sm.keycardDisarm(e)
+		}
 		if _, ok := event.(Level2CheckTimerEvent); ok {
 			if sm.level2Cycles >= maxLevel2Cycles {
+				sm.level2Exhausted = true
 				return StateDisarmed
 			}
 			return StateWaitingMovement
@@ -193,12 +325,24 @@ func (sm *StateMachine) getTransition(event Event) State {
 		}
 
 	case StateWaitingMovement:
+		if e, ok := event.(KeycardAuthorizedEvent); ok {
+			return sm.keycardDisarm(e)
+		}
 		if _, ok := event.(Level2CheckTimerEvent); ok {
 			return StateDelayArmed
 		}
+		if _, ok := event.(TowDetectedEvent); ok && sm.towApplies() {
+			sm.level2Cycles++
+			if sm.level2Cycles >= maxLevel2Cycles {
+				sm.level2Exhausted = true
+				return StateDisarmed
+			}
+			return StateTriggerLevel2
+		}
 		if _, ok := event.(BMXInterruptEvent); ok {
 			sm.level2Cycles++
 			if sm.level2Cycles >= maxLevel2Cycles {
+				sm.level2Exhausted = true
 				return StateDisarmed
 			}
 			return StateTriggerLevel2
@@ -216,6 +360,11 @@ func (sm *StateMachine) getTransition(event Event) State {
 		}
 
 	case StateSeatboxAccess:
+		if e, ok := event.(BatteryRemovedEvent); ok {
+			// Authorized battery swap: the seatbox was opened with
+			// seatbox:opened, so pulling the battery is expected.
+			sm.log.Info("battery removed during seatbox access, treating as swap", "battery", e.Battery)
+		}
 		if _, ok := event.(SeatboxClosedEvent); ok {
 			sm.seatboxLockClosed = true
 			return StateDelayArmed
@@ -231,6 +380,31 @@ func (sm *StateMachine) getTransition(event Event) State {
 		if _, ok := event.(RuntimeDisarmEvent); ok {
 			return StateDisarmed
 		}
+
+	case StateManualAlarm:
+		// Keep tracking vehicle + setting changes so the FSM resumes in the
+		// right state once the manual alarm ends.
+		if e, ok := event.(VehicleStateChangedEvent); ok {
+			sm.vehicleStandby = (e.State == VehicleStateStandby)
+		}
+		if e, ok := event.(AlarmModeChangedEvent); ok {
+			sm.alarmEnabled = e.Enabled
+		}
+		// On a locked scooter the manual alarm mustn't blind the real one:
+		// a trigger takes over as an L2 episode.
+		if sm.vehicleStandby && sm.alarmEnabled && sm.presenceMode != PresenceSuppress && isManualAlarmTrigger(event) {
+			sm.log.Warn("trigger during manual alarm, escalating to L2", "event", event.Type())
+			return StateTriggerLevel2
+		}
+		if _, ok := event.(ManualAlarmTimerEvent); ok {
+			return sm.resumeState()
+		}
+		if _, ok := event.(ManualStopEvent); ok {
+			return sm.resumeState()
+		}
+		if _, ok := event.(RuntimeDisarmEvent); ok {
+			return StateDisarmed
+		}
 	}
 
 	return sm.state
@@ -259,12 +433,18 @@ func (sm *StateMachine) enterState(ctx context.Context, state State) {
 		sm.onEnterWaitingMovement(ctx)
 	case StateSeatboxAccess:
 		sm.onEnterSeatboxAccess(ctx)
+	case StateManualAlarm:
+		sm.onEnterManualAlarm(ctx)
+	case StateLostMode:
+		sm.onEnterLostMode(ctx)
 	}
 }
 
 // exitState handles state exit actions
 func (sm *StateMachine) exitState(ctx context.Context, state State) {
 	switch state {
+	case StateInit:
+		sm.onExitInit(ctx)
 	case StateDisarmed:
 		sm.onExitDisarmed(ctx)
 	case StateDelayArmed:
@@ -281,5 +461,9 @@ func (sm *StateMachine) exitState(ctx context.Context, state State) {
 		sm.onExitWaitingMovement(ctx)
 	case StateSeatboxAccess:
 		sm.onExitSeatboxAccess(ctx)
+	case StateManualAlarm:
+		sm.onExitManualAlarm(ctx)
+	case StateLostMode:
+		sm.onExitLostMode(ctx)
 	}
 }
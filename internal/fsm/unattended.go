@@ -0,0 +1,69 @@
+package fsm
+
+import (
+	"strconv"
+	"time"
+)
+
+// defaultUnattendedTimeout is how long a parked scooter may sit without
+// rider activity before the owner is reminded it isn't locked.
+const defaultUnattendedTimeout = 600 // seconds, 0 disables
+
+// scheduleUnattended (re)starts the unattended timer while the vehicle is
+// parked with the alarm enabled, and stops it otherwise. Any call clears
+// a raised reminder: it means the rider or the situation changed.
+func (sm *StateMachine) scheduleUnattended(alarmEnabled bool) {
+	sm.clearUnattended()
+	if !sm.vehicleParked || !alarmEnabled || sm.unattendedTimeout <= 0 {
+		sm.stopTimer("unattended")
+		return
+	}
+	sm.startTimer("unattended", time.Duration(sm.unattendedTimeout)*time.Second, func() {
+		sm.SendEvent(UnattendedTimerEvent{})
+	})
+}
+
+// onVehicleState tracks whether the vehicle is parked, which is when the
+// unattended timer runs.
+func (sm *StateMachine) onVehicleState(state VehicleState) {
+	sm.vehicleParked = state == VehicleStateParked
+	sm.scheduleUnattended(sm.alarmEnabled)
+}
+
+// onRiderActivity restarts the unattended timer: the rider is still around.
+func (sm *StateMachine) onRiderActivity(source string) {
+	if !sm.vehicleParked {
+		return
+	}
+	sm.log.Debug("rider activity while parked", "source", source)
+	sm.scheduleUnattended(sm.alarmEnabled)
+}
+
+// unattendedElapsed raises the reminder once per unattended stretch.
+func (sm *StateMachine) unattendedElapsed() {
+	if !sm.vehicleParked || !sm.alarmEnabled || sm.unattended {
+		return
+	}
+	sm.unattended = true
+	sm.log.Warn("vehicle left unlocked and unattended", "timeout", sm.unattendedTimeout)
+	sm.publishFields(map[string]string{
+		"unattended":       "true",
+		"unattended-since": strconv.FormatInt(time.Now().Unix(), 10),
+	})
+	sm.notify(NotificationUnlockedUnattended)
+	if sm.unattendedHazards {
+		sm.blinkHazards()
+	}
+}
+
+// clearUnattended withdraws a raised reminder.
+func (sm *StateMachine) clearUnattended() {
+	if !sm.unattended {
+		return
+	}
+	sm.unattended = false
+	sm.publishFields(map[string]string{
+		"unattended":       "false",
+		"unattended-since": "",
+	})
+}
@@ -0,0 +1,21 @@
+package redis
+
+import "alarm-service/internal/fsm"
+
+// motionHealthFld is the motion hash field where motion-service publishes
+// its self-check: "ok" when the IMU is configured and reporting. Older
+// motion-service builds don't publish it; arming then treats it as healthy.
+const motionHealthFld = "health"
+
+// setupMotionHealthWatcher forwards motion-service health to the FSM as an
+// arming precondition.
+func (s *Subscriber) setupMotionHealthWatcher() {
+	s.motionStateWatcher.OnField(motionHealthFld, func(health string) error {
+		healthy := health == "ok"
+		if !healthy {
+			s.log.Warn("motion-service reports unhealthy", "health", health)
+		}
+		s.sm.SendEvent(fsm.ArmingFaultEvent{Fault: fsm.ArmingFaultMotionService, Active: !healthy})
+		return nil
+	})
+}
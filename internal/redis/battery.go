@@ -0,0 +1,64 @@
+package redis
+
+import (
+	"fmt"
+
+	"alarm-service/internal/fsm"
+)
+
+// Battery hashes watched for removal. The main batteries and the CB battery
+// report presence directly; the aux battery has no presence field, so a
+// voltage collapse stands in for a disconnect.
+var batteryPresenceHashes = []string{"battery:0", "battery:1", "cb-battery"}
+
+const (
+	auxBatteryHash = "aux-battery"
+	// auxDisconnectMillivolts is the aux voltage below which the 12 V aux
+	// battery counts as disconnected.
+	auxDisconnectMillivolts = 5000
+)
+
+// setupBatteryWatchers registers presence handlers on the battery hashes.
+func (s *Subscriber) setupBatteryWatchers() {
+	for _, hash := range batteryPresenceHashes {
+		w := s.ipc.NewHashWatcher(hash)
+		w.OnField("present", func(present string) error {
+			s.onBatteryPresence(hash, present == "true")
+			return nil
+		})
+		s.batteryWatchers = append(s.batteryWatchers, w)
+	}
+
+	aux := s.ipc.NewHashWatcher(auxBatteryHash)
+	aux.OnField("voltage", func(voltageStr string) error {
+		var millivolts int
+		if _, err := fmt.Sscanf(voltageStr, "%d", &millivolts); err != nil {
+			s.log.Warn("invalid aux-battery voltage value", "value", voltageStr, "error", err)
+			return nil
+		}
+		s.onBatteryPresence(auxBatteryHash, millivolts >= auxDisconnectMillivolts)
+		return nil
+	})
+	s.batteryWatchers = append(s.batteryWatchers, aux)
+}
+
+// onBatteryPresence sends BatteryRemovedEvent when a battery that was
+// present goes away. The first value seen (initial sync) only records the
+// baseline, so a scooter without a second battery doesn't trigger.
+func (s *Subscriber) onBatteryPresence(hash string, present bool) {
+	s.mu.Lock()
+	was, seen := s.batteryPresent[hash]
+	s.batteryPresent[hash] = present
+	enabled := s.batteryTriggerEnabled
+	s.mu.Unlock()
+
+	if !seen || !was || present {
+		return
+	}
+	if !enabled {
+		s.log.Info("battery removed, ignoring (battery-trigger disabled)", "battery", hash)
+		return
+	}
+	s.log.Warn("battery removed", "battery", hash, "state", s.sm.State().String())
+	s.sm.SendEvent(fsm.BatteryRemovedEvent{Battery: hash})
+}
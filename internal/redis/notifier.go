@@ -0,0 +1,269 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"alarm-service/internal/fsm"
+
+	ipc "github.com/librescoot/redis-ipc"
+)
+
+const (
+	// notificationsList is the Redis list the telematics service consumes
+	// alarm notifications from (LPUSH here, RPOP/BRPOP there).
+	notificationsList = "alarm:notifications"
+	// notificationsOutbox holds notifications not yet handed to the
+	// telematics list. It lives in Redis so a notification raised right
+	// before hibernation or a restart is delivered on the next start.
+	notificationsOutbox = "alarm:notifications:outbox"
+
+	// notificationDedupWindow drops repeats of the same notification, same
+	// type and detail.
+	notificationDedupWindow = 30 * time.Second
+	// defaultNotificationRateLimit caps notifications per type and hour.
+	defaultNotificationRateLimit = 20
+
+	notificationRetryMin = time.Second
+	notificationRetryMax = time.Minute
+)
+
+// notificationRateLimits overrides defaultNotificationRateLimit for the
+// types a false-trigger loop can produce over and over, and for tracking
+// updates, which are periodic by design.
+var notificationRateLimits = map[string]int{
+	fsm.NotificationLevel1:        6,
+	fsm.NotificationLevel2:        6,
+	fsm.NotificationSeatboxTamper: 6,
+	fsm.NotificationTowDetected:   6,
+	fsm.NotificationTracking:      120,
+}
+
+// notificationDedupExempt lists types that repeat on purpose.
+var notificationDedupExempt = map[string]bool{
+	fsm.NotificationTracking: true,
+}
+
+// notificationPayload is the JSON wire format of a notification.
+type notificationPayload struct {
+	Type      string           `json:"type"`
+	State     string           `json:"state"`
+	Timestamp int64            `json:"timestamp"`
+	Silent    bool             `json:"silent,omitempty"`
+	Detail    string           `json:"detail,omitempty"`
+	Position  *positionPayload `json:"position,omitempty"`
+	Distance  *float64         `json:"distance,omitempty"` // metres from the armed position
+}
+
+// positionPayload is the JSON wire format of a GPS position.
+type positionPayload struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Fix       string  `json:"fix"`
+	Timestamp int64   `json:"timestamp,omitempty"`
+}
+
+// notificationLimiter de-duplicates notifications per type and detail, and
+// rate limits them per type.
+type notificationLimiter struct {
+	sent map[string][]time.Time // accepted notifications per type, last hour
+	last map[string]time.Time   // last accepted per type and detail, dedup window
+}
+
+func newNotificationLimiter() *notificationLimiter {
+	return &notificationLimiter{
+		sent: make(map[string][]time.Time),
+		last: make(map[string]time.Time),
+	}
+}
+
+// allow reports whether a notification of the given type and detail may go
+// out now, and if not why. Accepted notifications are recorded.
+func (l *notificationLimiter) allow(notificationType, detail string, now time.Time) (bool, string) {
+	hourAgo := now.Add(-time.Hour)
+	keep := l.sent[notificationType][:0]
+	for _, t := range l.sent[notificationType] {
+		if t.After(hourAgo) {
+			keep = append(keep, t)
+		}
+	}
+	l.sent[notificationType] = keep
+
+	for key, t := range l.last {
+		if now.Sub(t) >= notificationDedupWindow {
+			delete(l.last, key)
+		}
+	}
+	key := notificationType + "\x00" + detail
+	if _, dup := l.last[key]; dup && !notificationDedupExempt[notificationType] {
+		return false, "duplicate"
+	}
+
+	limit, ok := notificationRateLimits[notificationType]
+	if !ok {
+		limit = defaultNotificationRateLimit
+	}
+	if len(keep) >= limit {
+		return false, "rate-limited"
+	}
+	l.sent[notificationType] = append(keep, now)
+	l.last[key] = now
+	return true, ""
+}
+
+// Notifier pushes alarm notifications onto the telematics queue. Notify
+// writes to a persistent outbox; a background worker moves the outbox onto
+// alarm:notifications, retrying with backoff while Redis is unavailable.
+type Notifier struct {
+	ipc     *ipc.Client
+	log     *slog.Logger
+	mu      sync.Mutex
+	limiter *notificationLimiter
+	pending []string // payloads that could not be written to the outbox yet
+	wake    chan struct{}
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// NewNotifier creates a new Notifier
+func NewNotifier(client *Client, log *slog.Logger) *Notifier {
+	return &Notifier{
+		ipc:     client.ipc,
+		log:     log,
+		limiter: newNotificationLimiter(),
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// Start starts the delivery worker, which first flushes notifications left
+// in the outbox by a previous run.
+func (n *Notifier) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	n.cancel = cancel
+	n.done = make(chan struct{})
+	go n.run(ctx)
+	n.signal()
+}
+
+// Stop stops the delivery worker. Undelivered notifications stay in the
+// outbox for the next start.
+func (n *Notifier) Stop() {
+	if n.cancel == nil {
+		return
+	}
+	n.cancel()
+	<-n.done
+	if err := n.flush(); err != nil {
+		n.log.Warn("notifications left in outbox", "error", err)
+	}
+}
+
+// Notify queues a notification for the telematics service. Duplicates and
+// notifications over the per-type rate limit are dropped.
+func (n *Notifier) Notify(notification fsm.Notification) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if ok, reason := n.limiter.allow(notification.Type, notification.Detail, notification.Timestamp); !ok {
+		n.log.Info("dropping notification", "type", notification.Type, "reason", reason)
+		return nil
+	}
+
+	payload := notificationPayload{
+		Type:      notification.Type,
+		State:     notification.State,
+		Timestamp: notification.Timestamp.Unix(),
+		Silent:    notification.Silent,
+		Detail:    notification.Detail,
+	}
+	if p := notification.Position; p.Valid() {
+		payload.Position = &positionPayload{
+			Latitude:  p.Latitude,
+			Longitude: p.Longitude,
+			Fix:       p.Fix,
+		}
+		if !p.Time.IsZero() {
+			payload.Position.Timestamp = p.Time.Unix()
+		}
+	}
+	if notification.HasDistance {
+		payload.Distance = &notification.Distance
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	if len(n.pending) == 0 {
+		_, err := n.ipc.RPush(notificationsOutbox, string(data))
+		if err == nil {
+			n.signal()
+			return nil
+		}
+		n.log.Warn("failed to write notification outbox, will retry", "type", notification.Type, "error", err)
+	}
+	// Keep order behind anything already waiting for the outbox.
+	n.pending = append(n.pending, string(data))
+	n.signal()
+	return nil
+}
+
+func (n *Notifier) signal() {
+	select {
+	case n.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (n *Notifier) run(ctx context.Context) {
+	defer close(n.done)
+
+	backoff := notificationRetryMin
+	for {
+		var retry <-chan time.Time
+		if err := n.flush(); err != nil {
+			n.log.Warn("failed to deliver notifications, retrying", "error", err, "backoff", backoff)
+			retry = time.After(backoff)
+			backoff = min(backoff*2, notificationRetryMax)
+		} else {
+			backoff = notificationRetryMin
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-n.wake:
+		case <-retry:
+		}
+	}
+}
+
+// flush writes pending payloads to the outbox and moves the outbox, oldest
+// first, onto the telematics list.
+func (n *Notifier) flush() error {
+	n.mu.Lock()
+	for len(n.pending) > 0 {
+		if _, err := n.ipc.RPush(notificationsOutbox, n.pending[0]); err != nil {
+			n.mu.Unlock()
+			return fmt.Errorf("write outbox: %w", err)
+		}
+		n.pending = n.pending[1:]
+	}
+	n.mu.Unlock()
+
+	for {
+		// LMOVE is atomic, so a failure leaves the notification in the
+		// outbox and a retry can't deliver it twice.
+		err := n.ipc.Raw().LMove(n.ipc.Context(), notificationsOutbox, notificationsList, "LEFT", "LEFT").Err()
+		if err == ipc.ErrNil {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("move outbox: %w", err)
+		}
+	}
+}
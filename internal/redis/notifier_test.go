@@ -0,0 +1,59 @@
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"alarm-service/internal/fsm"
+)
+
+func TestNotificationLimiter_Dedup(t *testing.T) {
+	l := newNotificationLimiter()
+	now := time.Now()
+
+	if ok, _ := l.allow(fsm.NotificationArmed, "", now); !ok {
+		t.Fatal("expected first notification to pass")
+	}
+	if ok, reason := l.allow(fsm.NotificationArmed, "", now.Add(5*time.Second)); ok || reason != "duplicate" {
+		t.Errorf("expected duplicate within window, got ok=%v reason=%q", ok, reason)
+	}
+	if ok, _ := l.allow(fsm.NotificationLevel1, "", now.Add(5*time.Second)); !ok {
+		t.Error("expected other types to be unaffected")
+	}
+	if ok, _ := l.allow(fsm.NotificationArmed, "", now.Add(notificationDedupWindow)); !ok {
+		t.Error("expected notification to pass after dedup window")
+	}
+}
+
+func TestNotificationLimiter_DedupByDetail(t *testing.T) {
+	l := newNotificationLimiter()
+	now := time.Now()
+
+	if ok, _ := l.allow(fsm.NotificationDisarmedAfterAlarm, "", now); !ok {
+		t.Fatal("expected first disarm to pass")
+	}
+	if ok, reason := l.allow(fsm.NotificationDisarmedAfterAlarm, "keycard:04a1b2", now.Add(5*time.Second)); !ok {
+		t.Errorf("expected keycard disarm to pass, got %q", reason)
+	}
+	if ok, reason := l.allow(fsm.NotificationDisarmedAfterAlarm, "keycard:04a1b2", now.Add(10*time.Second)); ok || reason != "duplicate" {
+		t.Errorf("expected repeated detail to be a duplicate, got ok=%v reason=%q", ok, reason)
+	}
+}
+
+func TestNotificationLimiter_RateLimit(t *testing.T) {
+	l := newNotificationLimiter()
+	now := time.Now()
+	limit := notificationRateLimits[fsm.NotificationLevel2]
+
+	for i := 0; i < limit; i++ {
+		if ok, _ := l.allow(fsm.NotificationLevel2, "", now.Add(time.Duration(i)*time.Minute)); !ok {
+			t.Fatalf("expected notification %d to pass", i)
+		}
+	}
+	if ok, reason := l.allow(fsm.NotificationLevel2, "", now.Add(time.Duration(limit)*time.Minute)); ok || reason != "rate-limited" {
+		t.Errorf("expected rate limit, got ok=%v reason=%q", ok, reason)
+	}
+	if ok, _ := l.allow(fsm.NotificationLevel2, "", now.Add(time.Hour+time.Minute)); !ok {
+		t.Error("expected the oldest notification to age out after an hour")
+	}
+}
@@ -0,0 +1,58 @@
+package redis
+
+import (
+	"fmt"
+	"strings"
+
+	"alarm-service/internal/fsm"
+)
+
+// parsePresenceDevices parses settings alarm.presence-devices: a
+// comma-separated list of BLE MAC addresses, each optionally followed by
+// "=suppress" (default) or "=disarm", e.g.
+// "AA:BB:CC:DD:EE:FF=disarm,11:22:33:44:55:66".
+func parsePresenceDevices(spec string) (map[string]fsm.PresenceMode, error) {
+	devices := make(map[string]fsm.PresenceMode)
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		mac, modeStr, hasMode := strings.Cut(item, "=")
+		mode := fsm.PresenceSuppress
+		if hasMode {
+			var ok bool
+			if mode, ok = fsm.ParsePresenceMode(strings.TrimSpace(modeStr)); !ok {
+				return nil, fmt.Errorf("presence device %q: unknown mode %q", mac, modeStr)
+			}
+		}
+		devices[strings.ToUpper(strings.TrimSpace(mac))] = mode
+	}
+	return devices, nil
+}
+
+// onBLEConnection reports the connected BLE device to the FSM as owner
+// presence if it is trusted, and its disconnect as absence.
+func (s *Subscriber) onBLEConnection(connection string) {
+	device := ""
+	if connection == "connected" {
+		mac, err := s.bleWatcher.Fetch("mac-address")
+		if err != nil {
+			s.log.Warn("failed to read connected ble device", "error", err)
+		}
+		device = strings.ToUpper(mac)
+	}
+
+	s.mu.Lock()
+	mode, trusted := s.presenceDevices[device]
+	if !trusted {
+		device, mode = "", fsm.PresenceNone
+	}
+	changed := device != s.presentDevice
+	s.presentDevice = device
+	s.mu.Unlock()
+
+	if changed {
+		s.sm.SendEvent(fsm.PresenceChangedEvent{Device: device, Mode: mode})
+	}
+}
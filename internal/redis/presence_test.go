@@ -0,0 +1,27 @@
+package redis
+
+import (
+	"testing"
+
+	"alarm-service/internal/fsm"
+)
+
+func TestParsePresenceDevices(t *testing.T) {
+	devices, err := parsePresenceDevices("aa:bb:cc:dd:ee:ff=disarm, 11:22:33:44:55:66")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if devices["AA:BB:CC:DD:EE:FF"] != fsm.PresenceDisarm {
+		t.Errorf("expected disarm mode, got %v", devices["AA:BB:CC:DD:EE:FF"])
+	}
+	if devices["11:22:33:44:55:66"] != fsm.PresenceSuppress {
+		t.Errorf("expected suppress by default, got %v", devices["11:22:33:44:55:66"])
+	}
+
+	if devices, err := parsePresenceDevices(""); err != nil || len(devices) != 0 {
+		t.Errorf("expected no devices for empty spec, got %v, %v", devices, err)
+	}
+	if _, err := parsePresenceDevices("AA:BB:CC:DD:EE:FF=ignore"); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+}
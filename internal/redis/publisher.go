@@ -28,6 +28,26 @@ func (p *Publisher) PublishStatus(status string) error {
 	return nil
 }
 
+// PublishField publishes an auxiliary field of the alarm hash
+func (p *Publisher) PublishField(field, value string) error {
+	if err := p.alarmPub.Set(field, value); err != nil {
+		return fmt.Errorf("failed to publish alarm %s: %w", field, err)
+	}
+	return nil
+}
+
+// PublishFields publishes several auxiliary fields of the alarm hash at once
+func (p *Publisher) PublishFields(fields map[string]string) error {
+	values := make(map[string]any, len(fields))
+	for field, value := range fields {
+		values[field] = value
+	}
+	if err := p.alarmPub.SetMany(values); err != nil {
+		return fmt.Errorf("failed to publish alarm fields: %w", err)
+	}
+	return nil
+}
+
 // RequestHibernate sends a hibernate-manual command to pm-service
 func (p *Publisher) RequestHibernate() error {
 	if _, err := p.ipc.LPush("scooter:power", "hibernate-manual"); err != nil {
@@ -35,3 +55,27 @@ func (p *Publisher) RequestHibernate() error {
 	}
 	return nil
 }
+
+// BlockDrive asks vehicle-service to refuse (or allow again) driving
+func (p *Publisher) BlockDrive(blocked bool) error {
+	command := "off"
+	if blocked {
+		command = "on"
+	}
+	if _, err := p.ipc.LPush("scooter:drive-block", command); err != nil {
+		return fmt.Errorf("failed to send drive-block command: %w", err)
+	}
+	return nil
+}
+
+// DriveBlocked reports whether the previous instance left driving blocked
+func (p *Publisher) DriveBlocked() (bool, error) {
+	value, err := p.alarmPub.Get("drive-blocked")
+	if err == ipc.ErrNil {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read drive-blocked: %w", err)
+	}
+	return value == "true", nil
+}
@@ -4,7 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"sync"
+	"time"
 
+	"alarm-service/internal/clock"
 	"alarm-service/internal/fsm"
 
 	ipc "github.com/librescoot/redis-ipc"
@@ -27,12 +30,29 @@ type Subscriber struct {
 	vehicleWatcher           *ipc.HashWatcher
 	settingsWatcher          *ipc.HashWatcher
 	powerManagerWatcher      *ipc.HashWatcher
+	gpsWatcher               *ipc.HashWatcher
+	engineECUWatcher         *ipc.HashWatcher
+	batteryWatchers          []*ipc.HashWatcher
+	keycardWatcher           *ipc.HashWatcher
+	bleWatcher               *ipc.HashWatcher
+	motionStateWatcher       *ipc.HashWatcher
 	motionWatcher            *ipc.Subscription[string]
 	ipc                      *ipc.Client
 	log                      *slog.Logger
 	sm                       *fsm.StateMachine
 	seatboxTriggerEnabled    bool
 	authorizedSeatboxPending bool
+	lastPosition             fsm.Position
+	wheelTamper              *wheelTamperDetector
+	tow                      *towDetector
+	mu                       sync.Mutex // guards the fields below across watcher goroutines
+	tamperLevels             map[fsm.TamperInput]fsm.TamperLevel
+	batteryTriggerEnabled    bool
+	batteryPresent           map[string]bool
+	presenceDevices          map[string]fsm.PresenceMode // trusted BLE MAC → mode
+	presentDevice            string                      // trusted device currently connected
+	tipOverHazards           bool
+	vehicleParked            bool
 }
 
 // NewSubscriber creates a new Subscriber with HashWatcher instances
@@ -41,15 +61,36 @@ func NewSubscriber(client *Client, sm *fsm.StateMachine, log *slog.Logger) *Subs
 		vehicleWatcher:        client.ipc.NewHashWatcher("vehicle"),
 		settingsWatcher:       client.ipc.NewHashWatcher("settings"),
 		powerManagerWatcher:   client.ipc.NewHashWatcher("power-manager"),
+		gpsWatcher:            client.ipc.NewHashWatcher("gps"),
+		engineECUWatcher:      client.ipc.NewHashWatcher(engineECUHash),
+		keycardWatcher:        client.ipc.NewHashWatcher("keycard"),
+		bleWatcher:            client.ipc.NewHashWatcher("ble"),
+		motionStateWatcher:    client.ipc.NewHashWatcher(motionHash),
 		ipc:                   client.ipc,
 		log:                   log,
 		sm:                    sm,
 		seatboxTriggerEnabled: true, // default: seatbox opening can trigger alarm
+		tamperLevels: map[fsm.TamperInput]fsm.TamperLevel{
+			fsm.TamperInputKickstand: fsm.TamperLevelL1,
+			fsm.TamperInputHandlebar: fsm.TamperLevelL2,
+			fsm.TamperInputBrake:     fsm.TamperLevelL1,
+		},
+		batteryTriggerEnabled: true,
+		batteryPresent:        make(map[string]bool),
+		presenceDevices:       make(map[string]fsm.PresenceMode),
 	}
 
 	s.setupVehicleWatcher()
 	s.setupSettingsWatcher()
 	s.setupPowerManagerWatcher()
+	s.setupGPSWatcher()
+	s.wheelTamper = newWheelTamperDetector(s.onWheelTamper)
+	s.tow = newTowDetector()
+	s.setupEngineECUWatcher()
+	s.setupBatteryWatchers()
+	s.setupAuthWatchers()
+	s.setupOrientationWatcher()
+	s.setupMotionHealthWatcher()
 
 	return s
 }
@@ -75,6 +116,9 @@ func (s *Subscriber) setupVehicleWatcher() {
 	s.vehicleWatcher.OnField("state", func(stateStr string) error {
 		state := fsm.ParseVehicleState(stateStr)
 		s.log.Debug("vehicle state changed", "state", state.String())
+		s.mu.Lock()
+		s.vehicleParked = state == fsm.VehicleStateParked
+		s.mu.Unlock()
 		s.sm.SendEvent(fsm.VehicleStateChangedEvent{State: state})
 		return nil
 	})
@@ -86,8 +130,45 @@ func (s *Subscriber) setupVehicleWatcher() {
 		return nil
 	})
 
+	s.vehicleWatcher.OnField("kickstand", func(value string) error {
+		s.riderActivity("kickstand")
+		s.sm.SendEvent(fsm.ArmingFaultEvent{Fault: fsm.ArmingFaultKickstandUp, Active: value == "up"})
+		if value == "up" {
+			s.sendInputTamper(fsm.TamperInputKickstand, value)
+		}
+		return nil
+	})
+
+	s.vehicleWatcher.OnField("handlebar:lock-sensor", func(value string) error {
+		s.riderActivity("handlebar")
+		if value == "unlocked" {
+			s.sendInputTamper(fsm.TamperInputHandlebar, value)
+		}
+		return nil
+	})
+
+	for _, field := range []string{"brake:left", "brake:right"} {
+		s.vehicleWatcher.OnField(field, func(value string) error {
+			s.riderActivity(field)
+			if value == "on" {
+				s.sendInputTamper(fsm.TamperInputBrake, value)
+			}
+			return nil
+		})
+	}
+
+	// Controls that only matter as signs of a rider around.
+	for _, field := range []string{"blinker:switch", "handlebar:position", "seatbox:button", "horn:button"} {
+		s.vehicleWatcher.OnField(field, func(string) error {
+			s.riderActivity(field)
+			return nil
+		})
+	}
+
 	s.vehicleWatcher.OnField("seatbox:lock", func(lockState string) error {
 		s.log.Debug("seatbox lock state changed", "state", lockState)
+		s.riderActivity("seatbox")
+		s.sm.SendEvent(fsm.ArmingFaultEvent{Fault: fsm.ArmingFaultSeatboxOpen, Active: lockState == "open"})
 		if lockState == "closed" {
 			s.authorizedSeatboxPending = false
 			s.sm.SendEvent(fsm.SeatboxClosedEvent{})
@@ -112,6 +193,35 @@ func (s *Subscriber) setupVehicleWatcher() {
 	})
 }
 
+// riderActivity tells the FSM someone is handling a parked scooter, which
+// restarts the unattended reminder. Filtered here so riding doesn't flood
+// the event queue.
+func (s *Subscriber) riderActivity(source string) {
+	s.mu.Lock()
+	parked := s.vehicleParked
+	s.mu.Unlock()
+	if parked {
+		s.sm.SendEvent(fsm.RiderActivityEvent{Source: source})
+	}
+}
+
+// sendInputTamper forwards a vehicle input change to the FSM if the input is
+// enabled and the alarm is armed or in L1. The inputs change all the time
+// while riding, and on initial sync, so other states are filtered here.
+func (s *Subscriber) sendInputTamper(input fsm.TamperInput, value string) {
+	s.mu.Lock()
+	level := s.tamperLevels[input]
+	s.mu.Unlock()
+	if level == fsm.TamperLevelOff {
+		return
+	}
+	switch state := s.sm.State(); state {
+	case fsm.StateArmed, fsm.StateTriggerLevel1Wait, fsm.StateTriggerLevel1, fsm.StateLostMode:
+		s.log.Warn("vehicle input changed while armed", "input", input, "value", value, "level", level.String(), "state", state.String())
+		s.sm.SendEvent(fsm.InputTamperEvent{Input: input, Level: level})
+	}
+}
+
 // setupSettingsWatcher registers handlers for alarm settings changes
 func (s *Subscriber) setupSettingsWatcher() {
 	s.settingsWatcher.OnField("alarm.enabled", func(alarmEnabled string) error {
@@ -149,6 +259,309 @@ func (s *Subscriber) setupSettingsWatcher() {
 		return nil
 	})
 
+	s.settingsWatcher.OnField("alarm.horn-max-per-hour", func(secondsStr string) error {
+		var seconds int
+		if _, err := fmt.Sscanf(secondsStr, "%d", &seconds); err != nil {
+			s.log.Error("invalid alarm.horn-max-per-hour value", "value", secondsStr, "error", err)
+			return nil
+		}
+		s.log.Debug("horn max per hour changed", "seconds", seconds)
+		s.sm.SendEvent(fsm.HornBudgetChangedEvent{MaxPerHour: seconds})
+		return nil
+	})
+
+	s.settingsWatcher.OnField("alarm.horn-max-per-night", func(secondsStr string) error {
+		var seconds int
+		if _, err := fmt.Sscanf(secondsStr, "%d", &seconds); err != nil {
+			s.log.Error("invalid alarm.horn-max-per-night value", "value", secondsStr, "error", err)
+			return nil
+		}
+		s.log.Debug("horn max per night changed", "seconds", seconds)
+		s.sm.SendEvent(fsm.HornBudgetChangedEvent{MaxPerNight: seconds})
+		return nil
+	})
+
+	s.settingsWatcher.OnField("alarm.horn-min-rest", func(secondsStr string) error {
+		var seconds int
+		if _, err := fmt.Sscanf(secondsStr, "%d", &seconds); err != nil {
+			s.log.Error("invalid alarm.horn-min-rest value", "value", secondsStr, "error", err)
+			return nil
+		}
+		s.log.Debug("horn min rest changed", "seconds", seconds)
+		s.sm.SendEvent(fsm.HornBudgetChangedEvent{MinRest: seconds})
+		return nil
+	})
+
+	s.settingsWatcher.OnField("alarm.horn-night", func(spec string) error {
+		start, end, err := clock.ParseRange(spec)
+		if err != nil {
+			s.log.Error("invalid alarm.horn-night value", "value", spec, "error", err)
+			return nil
+		}
+		s.log.Debug("horn night window changed", "value", spec)
+		s.sm.SendEvent(fsm.HornNightChangedEvent{Start: start, End: end})
+		return nil
+	})
+
+	s.settingsWatcher.OnField("alarm.geofence-radius", func(radiusStr string) error {
+		var radius int
+		if _, err := fmt.Sscanf(radiusStr, "%d", &radius); err != nil {
+			s.log.Error("invalid alarm.geofence-radius value", "value", radiusStr, "error", err)
+			return nil
+		}
+		s.log.Debug("geofence radius changed", "radius", radius)
+		s.sm.SendEvent(fsm.GeofenceRadiusChangedEvent{Radius: radius})
+		return nil
+	})
+
+	s.settingsWatcher.OnField("alarm.tracking-interval", func(intervalStr string) error {
+		var interval int
+		if _, err := fmt.Sscanf(intervalStr, "%d", &interval); err != nil {
+			s.log.Error("invalid alarm.tracking-interval value", "value", intervalStr, "error", err)
+			return nil
+		}
+		s.log.Debug("tracking interval changed", "interval", interval)
+		s.sm.SendEvent(fsm.TrackingIntervalChangedEvent{Interval: interval})
+		return nil
+	})
+
+	for setting, input := range map[string]fsm.TamperInput{
+		"alarm.kickstand-trigger": fsm.TamperInputKickstand,
+		"alarm.handlebar-trigger": fsm.TamperInputHandlebar,
+		"alarm.brake-trigger":     fsm.TamperInputBrake,
+	} {
+		s.settingsWatcher.OnField(setting, func(value string) error {
+			level, ok := fsm.ParseTamperLevel(value)
+			if !ok {
+				s.log.Error("invalid "+setting+" value", "value", value)
+				return nil
+			}
+			s.log.Info("input tamper setting changed", "input", input, "level", level.String())
+			s.mu.Lock()
+			s.tamperLevels[input] = level
+			s.mu.Unlock()
+			return nil
+		})
+	}
+
+	s.settingsWatcher.OnField("alarm.presence-devices", func(spec string) error {
+		devices, err := parsePresenceDevices(spec)
+		if err != nil {
+			s.log.Error("invalid alarm.presence-devices value", "value", spec, "error", err)
+			return nil
+		}
+		s.log.Info("presence devices changed", "devices", len(devices))
+		s.mu.Lock()
+		s.presenceDevices = devices
+		s.mu.Unlock()
+		return nil
+	})
+
+	s.settingsWatcher.OnField("alarm.auth-failure-threshold", func(thresholdStr string) error {
+		var threshold int
+		if _, err := fmt.Sscanf(thresholdStr, "%d", &threshold); err != nil {
+			s.log.Error("invalid alarm.auth-failure-threshold value", "value", thresholdStr, "error", err)
+			return nil
+		}
+		s.log.Debug("auth failure threshold changed", "threshold", threshold)
+		s.sm.SendEvent(fsm.AuthFailureThresholdChangedEvent{Threshold: threshold})
+		return nil
+	})
+
+	s.settingsWatcher.OnField("alarm.auth-failure-window", func(windowStr string) error {
+		var window int
+		if _, err := fmt.Sscanf(windowStr, "%d", &window); err != nil {
+			s.log.Error("invalid alarm.auth-failure-window value", "value", windowStr, "error", err)
+			return nil
+		}
+		s.log.Debug("auth failure window changed", "window", window)
+		s.sm.SendEvent(fsm.AuthFailureWindowChangedEvent{Window: window})
+		return nil
+	})
+
+	s.settingsWatcher.OnField("alarm.auth-failure-level", func(value string) error {
+		level, ok := fsm.ParseTamperLevel(value)
+		if !ok {
+			s.log.Error("invalid alarm.auth-failure-level value", "value", value)
+			return nil
+		}
+		s.log.Info("auth failure level changed", "level", level.String())
+		s.sm.SendEvent(fsm.AuthFailureLevelChangedEvent{Level: level})
+		return nil
+	})
+
+	s.settingsWatcher.OnField("alarm.battery-trigger", func(batteryTrigger string) error {
+		enabled := batteryTrigger != "false"
+		s.log.Info("battery-trigger setting changed", "enabled", enabled)
+		s.mu.Lock()
+		s.batteryTriggerEnabled = enabled
+		s.mu.Unlock()
+		return nil
+	})
+
+	s.settingsWatcher.OnField("alarm.tipover-hazards", func(tipOverHazards string) error {
+		enabled := tipOverHazards == "true"
+		s.log.Info("tipover-hazards setting changed", "enabled", enabled)
+		s.mu.Lock()
+		s.tipOverHazards = enabled
+		s.mu.Unlock()
+		return nil
+	})
+
+	s.settingsWatcher.OnField("alarm.wheel-trigger", func(wheelTrigger string) error {
+		enabled := wheelTrigger != "false"
+		s.log.Info("wheel-trigger setting changed", "enabled", enabled)
+		s.wheelTamper.setEnabled(enabled)
+		return nil
+	})
+
+	s.settingsWatcher.OnField("alarm.wheel-speed-threshold", func(speedStr string) error {
+		var speed int
+		if _, err := fmt.Sscanf(speedStr, "%d", &speed); err != nil {
+			s.log.Error("invalid alarm.wheel-speed-threshold value", "value", speedStr, "error", err)
+			return nil
+		}
+		s.log.Debug("wheel speed threshold changed", "speed", speed)
+		s.wheelTamper.setThreshold(speed)
+		return nil
+	})
+
+	s.settingsWatcher.OnField("alarm.wheel-min-duration", func(durationStr string) error {
+		var duration int
+		if _, err := fmt.Sscanf(durationStr, "%d", &duration); err != nil || duration <= 0 {
+			s.log.Error("invalid alarm.wheel-min-duration value", "value", durationStr, "error", err)
+			return nil
+		}
+		s.log.Debug("wheel min duration changed", "duration", duration)
+		s.wheelTamper.setMinDuration(time.Duration(duration) * time.Second)
+		return nil
+	})
+
+	s.settingsWatcher.OnField("alarm.unattended-timeout", func(timeoutStr string) error {
+		var timeout int
+		if _, err := fmt.Sscanf(timeoutStr, "%d", &timeout); err != nil || timeout < 0 {
+			s.log.Error("invalid alarm.unattended-timeout value", "value", timeoutStr, "error", err)
+			return nil
+		}
+		s.log.Debug("unattended timeout changed", "timeout", timeout)
+		s.sm.SendEvent(fsm.UnattendedTimeoutChangedEvent{Timeout: timeout})
+		return nil
+	})
+
+	s.settingsWatcher.OnField("alarm.unattended-hazards", func(hazards string) error {
+		enabled := hazards == "true"
+		s.log.Info("unattended-hazards setting changed", "enabled", enabled)
+		s.sm.SendEvent(fsm.UnattendedHazardsChangedEvent{Enabled: enabled})
+		return nil
+	})
+
+	s.settingsWatcher.OnField("alarm.immobilizer", func(immobilizer string) error {
+		enabled := immobilizer == "true"
+		s.log.Info("immobilizer setting changed", "enabled", enabled)
+		s.sm.SendEvent(fsm.ImmobilizerChangedEvent{Enabled: enabled})
+		return nil
+	})
+
+	s.settingsWatcher.OnField("alarm.lost-mode-interval", func(intervalStr string) error {
+		var interval int
+		if _, err := fmt.Sscanf(intervalStr, "%d", &interval); err != nil || interval <= 0 {
+			s.log.Error("invalid alarm.lost-mode-interval value", "value", intervalStr, "error", err)
+			return nil
+		}
+		s.log.Debug("lost mode interval changed", "interval", interval)
+		s.sm.SendEvent(fsm.LostModeIntervalChangedEvent{Interval: interval})
+		return nil
+	})
+
+	s.settingsWatcher.OnField("alarm.lost-mode-beacon", func(intervalStr string) error {
+		var interval int
+		if _, err := fmt.Sscanf(intervalStr, "%d", &interval); err != nil || interval < 0 {
+			s.log.Error("invalid alarm.lost-mode-beacon value", "value", intervalStr, "error", err)
+			return nil
+		}
+		s.log.Debug("lost mode beacon changed", "interval", interval)
+		s.sm.SendEvent(fsm.LostModeBeaconChangedEvent{Interval: interval})
+		return nil
+	})
+
+	s.settingsWatcher.OnField("alarm.tow-trigger", func(towTrigger string) error {
+		enabled := towTrigger != "false"
+		s.log.Info("tow-trigger setting changed", "enabled", enabled)
+		s.tow.setEnabled(enabled)
+		return nil
+	})
+
+	s.settingsWatcher.OnField("alarm.tow-event-count", func(countStr string) error {
+		var count int
+		if _, err := fmt.Sscanf(countStr, "%d", &count); err != nil || count <= 0 {
+			s.log.Error("invalid alarm.tow-event-count value", "value", countStr, "error", err)
+			return nil
+		}
+		s.log.Debug("tow event count changed", "count", count)
+		s.tow.setCount(count)
+		return nil
+	})
+
+	s.settingsWatcher.OnField("alarm.tow-window", func(windowStr string) error {
+		var window int
+		if _, err := fmt.Sscanf(windowStr, "%d", &window); err != nil || window <= 0 {
+			s.log.Error("invalid alarm.tow-window value", "value", windowStr, "error", err)
+			return nil
+		}
+		s.log.Debug("tow window changed", "window", window)
+		s.tow.setWindow(time.Duration(window) * time.Second)
+		return nil
+	})
+
+	s.settingsWatcher.OnField("alarm.mode", func(mode string) error {
+		silent := mode == "silent"
+		s.log.Info("alarm mode changed", "mode", mode, "silent", silent)
+		s.sm.SendEvent(fsm.SilentModeChangedEvent{Silent: silent})
+		return nil
+	})
+
+	s.settingsWatcher.OnField("alarm.quiet-hours", func(spec string) error {
+		ranges, err := fsm.ParseQuietHours(spec)
+		if err != nil {
+			s.log.Error("invalid alarm.quiet-hours value", "value", spec, "error", err)
+			return nil
+		}
+		s.log.Info("quiet hours changed", "value", spec)
+		s.sm.SendEvent(fsm.QuietHoursChangedEvent{Ranges: ranges})
+		return nil
+	})
+
+	s.settingsWatcher.OnField("alarm.quiet-hours-timezone", func(tz string) error {
+		loc := time.Local
+		if tz != "" {
+			var err error
+			if loc, err = time.LoadLocation(tz); err != nil {
+				s.log.Error("invalid alarm.quiet-hours-timezone value", "value", tz, "error", err)
+				return nil
+			}
+		}
+		s.log.Info("quiet hours timezone changed", "timezone", loc.String())
+		s.sm.SendEvent(fsm.QuietHoursTimezoneChangedEvent{Location: loc})
+		return nil
+	})
+
+	s.settingsWatcher.OnField("alarm.arming-preconditions", func(value string) error {
+		action, ok := fsm.ParseArmingAction(value)
+		if !ok {
+			s.log.Error("invalid alarm.arming-preconditions value", "value", value)
+			return nil
+		}
+		s.log.Info("arming-preconditions setting changed", "action", action)
+		s.sm.SendEvent(fsm.ArmingActionChangedEvent{Action: action})
+		return nil
+	})
+
+	s.settingsWatcher.OnField("alarm.quiet-hours-mode", func(mode string) error {
+		s.log.Info("quiet hours mode changed", "mode", mode)
+		s.sm.SendEvent(fsm.QuietHoursModeChangedEvent{Mode: fsm.ParseQuietMode(mode)})
+		return nil
+	})
+
 	s.settingsWatcher.OnField("alarm.seatbox-trigger", func(seatboxTrigger string) error {
 		enabled := seatboxTrigger == "true"
 		s.log.Info("seatbox-trigger setting changed", "enabled", enabled)
@@ -198,6 +611,99 @@ func (s *Subscriber) setupPowerManagerWatcher() {
 	})
 }
 
+// setupGPSWatcher forwards gps hash updates to the FSM as position
+// snapshots. The position spans several fields, so any of them changing
+// re-reads the whole hash; unchanged snapshots are dropped.
+func (s *Subscriber) setupGPSWatcher() {
+	for _, field := range []string{"latitude", "longitude", "fix", "timestamp"} {
+		s.gpsWatcher.OnField(field, func(string) error {
+			fields, err := s.gpsWatcher.FetchAll()
+			if err != nil {
+				s.log.Warn("failed to read gps hash", "error", err)
+				return nil
+			}
+			position := fsm.ParsePosition(fields)
+			if position == s.lastPosition {
+				return nil
+			}
+			s.lastPosition = position
+			s.log.Debug("gps position changed", "latitude", position.Latitude, "longitude", position.Longitude, "fix", position.Fix)
+			s.sm.SendEvent(fsm.GPSUpdateEvent{Position: position})
+			return nil
+		})
+	}
+}
+
+// setupAuthWatchers forwards failed keycard and BLE unlock attempts to the
+// FSM, which counts them, and authorized keycard taps, which disarm a
+// running episode. keycard-service sets keycard authentication=failed or
+// passed (with uid) on every read; the BLE service publishes an auth-failed
+// event on the ble channel.
+func (s *Subscriber) setupAuthWatchers() {
+	s.keycardWatcher.OnField("authentication", func(result string) error {
+		s.riderActivity("keycard")
+		switch result {
+		case "failed":
+			s.sm.SendEvent(fsm.AuthFailureEvent{Source: "keycard"})
+		case "passed":
+			uid, err := s.keycardWatcher.Fetch("uid")
+			if err != nil && err != ipc.ErrNil {
+				s.log.Warn("failed to read keycard uid", "error", err)
+			}
+			s.sm.SendEvent(fsm.KeycardAuthorizedEvent{UID: uid})
+		}
+		return nil
+	})
+
+	s.bleWatcher.OnEvent("auth-failed", func() error {
+		s.sm.SendEvent(fsm.AuthFailureEvent{Source: "ble"})
+		return nil
+	})
+
+	s.bleWatcher.OnField("connection", func(connection string) error {
+		s.onBLEConnection(connection)
+		return nil
+	})
+}
+
+// setupEngineECUWatcher feeds engine-ECU speed and RPM into the wheel
+// tamper detector.
+func (s *Subscriber) setupEngineECUWatcher() {
+	s.engineECUWatcher.OnField("speed", func(speedStr string) error {
+		var speed int
+		if speedStr != "" {
+			if _, err := fmt.Sscanf(speedStr, "%d", &speed); err != nil {
+				s.log.Warn("invalid engine-ecu speed value", "value", speedStr, "error", err)
+				return nil
+			}
+		}
+		s.wheelTamper.setSpeed(speed)
+		return nil
+	})
+
+	s.engineECUWatcher.OnField("rpm", func(rpmStr string) error {
+		var rpm int
+		if rpmStr != "" {
+			if _, err := fmt.Sscanf(rpmStr, "%d", &rpm); err != nil {
+				s.log.Warn("invalid engine-ecu rpm value", "value", rpmStr, "error", err)
+				return nil
+			}
+		}
+		s.wheelTamper.setRPM(rpm)
+		return nil
+	})
+}
+
+// onWheelTamper forwards a sustained wheel movement to the FSM. The wheel
+// turns all the time while riding, so only armed, L1 and lost mode care.
+func (s *Subscriber) onWheelTamper(speed, rpm int) {
+	switch state := s.sm.State(); state {
+	case fsm.StateArmed, fsm.StateTriggerLevel1Wait, fsm.StateTriggerLevel1, fsm.StateLostMode:
+		s.log.Warn("wheel turning while armed", "speed", speed, "rpm", rpm, "state", state.String())
+		s.sm.SendEvent(fsm.WheelTamperEvent{Speed: speed, RPM: rpm})
+	}
+}
+
 // Start starts all watchers with initial state sync and signals the FSM to
 // leave StateInit. StartWithSync delivers current field values via OnField
 // callbacks before returning, so the FSM receives AlarmModeChangedEvent and
@@ -217,6 +723,39 @@ func (s *Subscriber) Start() error {
 		return fmt.Errorf("failed to start power-manager watcher: %w", err)
 	}
 
+	if err := s.gpsWatcher.StartWithSync(); err != nil {
+		return fmt.Errorf("failed to start gps watcher: %w", err)
+	}
+
+	if err := s.engineECUWatcher.StartWithSync(); err != nil {
+		return fmt.Errorf("failed to start engine-ecu watcher: %w", err)
+	}
+
+	for _, w := range s.batteryWatchers {
+		if err := w.StartWithSync(); err != nil {
+			return fmt.Errorf("failed to start battery watcher: %w", err)
+		}
+	}
+
+	if err := s.motionStateWatcher.StartWithSync(); err != nil {
+		return fmt.Errorf("failed to start motion state watcher: %w", err)
+	}
+
+	// Plain Start: a stale authentication=failed left in the hash is not a
+	// new attempt.
+	if err := s.keycardWatcher.Start(); err != nil {
+		return fmt.Errorf("failed to start keycard watcher: %w", err)
+	}
+
+	if err := s.bleWatcher.Start(); err != nil {
+		return fmt.Errorf("failed to start ble watcher: %w", err)
+	}
+	// The connection state, unlike auth results, is current state: pick up
+	// a phone that was already connected.
+	if connection, err := s.bleWatcher.Fetch("connection"); err == nil {
+		s.onBLEConnection(connection)
+	}
+
 	s.sm.SendEvent(fsm.InitCompleteEvent{})
 
 	s.log.Info("subscribing to motion:interrupt")
@@ -228,6 +767,15 @@ func (s *Subscriber) Start() error {
 			return nil
 		}
 		s.log.Info("motion event received", "type", evt.Type, "engine", evt.Engine, "timestamp", evt.Timestamp)
+		if evt.Type == motionTypeSustained && s.tow.isEnabled() {
+			s.sm.SendEvent(fsm.TowDetectedEvent{Source: fsm.TowSourceMotionService})
+			return nil
+		}
+		if n := s.tow.record(time.Now()); n > 0 {
+			s.log.Warn("sustained motion detected", "events", n)
+			s.sm.SendEvent(fsm.TowDetectedEvent{Events: n, Source: fsm.TowSourceEventCount})
+			return nil
+		}
 		s.sm.SendEvent(fsm.BMXInterruptEvent{
 			Timestamp: evt.Timestamp,
 			Data:      evt.Type,
@@ -246,6 +794,15 @@ func (s *Subscriber) Stop() {
 	s.vehicleWatcher.Stop()
 	s.settingsWatcher.Stop()
 	s.powerManagerWatcher.Stop()
+	s.gpsWatcher.Stop()
+	s.engineECUWatcher.Stop()
+	s.wheelTamper.stop()
+	for _, w := range s.batteryWatchers {
+		w.Stop()
+	}
+	s.keycardWatcher.Stop()
+	s.bleWatcher.Stop()
+	s.motionStateWatcher.Stop()
 	if s.motionWatcher != nil {
 		s.motionWatcher.Unsubscribe()
 	}
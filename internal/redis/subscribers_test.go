@@ -0,0 +1,58 @@
+package redis
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"alarm-service/internal/fsm"
+)
+
+// nopDeps satisfies the FSM's outbound interfaces without doing anything.
+type nopDeps struct{}
+
+func (nopDeps) PrepareHibernation(context.Context) error                  { return nil }
+func (nopDeps) PublishStatus(string) error                                { return nil }
+func (nopDeps) PublishField(string, string) error                         { return nil }
+func (nopDeps) PublishFields(map[string]string) error                     { return nil }
+func (nopDeps) Acquire(string) error                                      { return nil }
+func (nopDeps) Release() error                                            { return nil }
+func (nopDeps) RequestHibernate() error                                   { return nil }
+func (nopDeps) Start(time.Duration) error                                 { return nil }
+func (nopDeps) StartHazardsOnly(time.Duration) error                      { return nil }
+func (nopDeps) Stop() error                                               { return nil }
+func (nopDeps) SetHornEnabled(bool)                                       {}
+func (nopDeps) SetHornBudget(time.Duration, time.Duration, time.Duration) {}
+func (nopDeps) SetHornNight(int, int)                                     {}
+func (nopDeps) BlinkHazards() error                                       { return nil }
+func (nopDeps) BlinkTipOver() error                                       { return nil }
+
+func waitForState(t *testing.T, sm *fsm.StateMachine, want fsm.State) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for sm.State() != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %s, got %s", want, sm.State())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestOnWheelTamper_LostModeEscalatesToL2(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	deps := nopDeps{}
+	sm := fsm.New(deps, deps, deps, deps, deps, 10, log)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go sm.Run(ctx)
+
+	sm.SendEvent(fsm.LostModeEvent{Enabled: true})
+	sm.SendEvent(fsm.InitCompleteEvent{})
+	waitForState(t, sm, fsm.StateLostMode)
+
+	s := &Subscriber{sm: sm, log: log}
+	s.onWheelTamper(8, 120)
+	waitForState(t, sm, fsm.StateTriggerLevel2)
+}
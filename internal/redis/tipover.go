@@ -0,0 +1,30 @@
+package redis
+
+import "alarm-service/internal/fsm"
+
+// motionOrientationFld is the motion hash field where motion-service
+// publishes the scooter's orientation from the tilt detector: "upright" or
+// "tipped-over".
+const motionOrientationFld = "orientation"
+
+// setupOrientationWatcher forwards tip-over and upright reports from
+// motion-service to the FSM.
+func (s *Subscriber) setupOrientationWatcher() {
+	s.motionStateWatcher.OnField(motionOrientationFld, func(orientation string) error {
+		var tippedOver bool
+		switch orientation {
+		case "tipped-over":
+			tippedOver = true
+		case "upright":
+		default:
+			s.log.Warn("unknown motion orientation value", "value", orientation)
+			return nil
+		}
+		s.mu.Lock()
+		hazards := s.tipOverHazards
+		s.mu.Unlock()
+		s.log.Debug("motion orientation changed", "orientation", orientation)
+		s.sm.SendEvent(fsm.TipOverEvent{TippedOver: tippedOver, Hazards: hazards})
+		return nil
+	})
+}
@@ -0,0 +1,91 @@
+package redis
+
+import (
+	"sync"
+	"time"
+)
+
+// Tow detection defaults. A single bump or a gust produces a handful of
+// motion interrupts; a scooter being lifted onto a van or towed keeps the
+// sensor busy for many seconds.
+const (
+	defaultTowEventCount = 8
+	defaultTowWindow     = 20 * time.Second
+	// motionTypeSustained is the motion-service event type for a motion
+	// episode its own filter already judged sustained.
+	motionTypeSustained = "sustained"
+)
+
+// towDetector counts motion interrupts in a sliding window and reports a
+// sustained motion episode once count events fall inside it. The window is
+// emptied on every report, so a long tow reports every count events. Safe
+// for concurrent use.
+type towDetector struct {
+	mu      sync.Mutex
+	enabled bool
+	count   int
+	window  time.Duration
+	events  []time.Time
+}
+
+func newTowDetector() *towDetector {
+	return &towDetector{
+		enabled: true,
+		count:   defaultTowEventCount,
+		window:  defaultTowWindow,
+	}
+}
+
+// setEnabled turns detection on or off.
+func (d *towDetector) setEnabled(enabled bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.enabled = enabled
+	d.events = nil
+}
+
+// setCount sets how many events within the window make an episode.
+func (d *towDetector) setCount(count int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.count = count
+}
+
+// setWindow sets the sliding window length.
+func (d *towDetector) setWindow(window time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.window = window
+}
+
+// isEnabled reports whether tow detection is on.
+func (d *towDetector) isEnabled() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.enabled
+}
+
+// record adds a motion event at now and returns the number of events in the
+// window if they make a sustained episode, 0 otherwise.
+func (d *towDetector) record(now time.Time) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.enabled || d.count <= 0 {
+		return 0
+	}
+
+	cutoff := now.Add(-d.window)
+	keep := d.events[:0]
+	for _, t := range d.events {
+		if t.After(cutoff) {
+			keep = append(keep, t)
+		}
+	}
+	d.events = append(keep, now)
+
+	if n := len(d.events); n >= d.count {
+		d.events = nil
+		return n
+	}
+	return 0
+}
@@ -0,0 +1,53 @@
+package redis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTowDetector_FiresOnSustainedMotion(t *testing.T) {
+	d := newTowDetector()
+	d.setCount(3)
+	d.setWindow(10 * time.Second)
+
+	now := time.Now()
+	if n := d.record(now); n != 0 {
+		t.Fatalf("expected no episode after one event, got %d", n)
+	}
+	if n := d.record(now.Add(2 * time.Second)); n != 0 {
+		t.Fatalf("expected no episode after two events, got %d", n)
+	}
+	if n := d.record(now.Add(4 * time.Second)); n != 3 {
+		t.Fatalf("expected episode of 3 events, got %d", n)
+	}
+	// The window starts over after a report.
+	if n := d.record(now.Add(5 * time.Second)); n != 0 {
+		t.Errorf("expected window reset after report, got %d", n)
+	}
+}
+
+func TestTowDetector_IgnoresSparseMotion(t *testing.T) {
+	d := newTowDetector()
+	d.setCount(3)
+	d.setWindow(10 * time.Second)
+
+	now := time.Now()
+	for i := 0; i < 10; i++ {
+		if n := d.record(now.Add(time.Duration(i) * 6 * time.Second)); n != 0 {
+			t.Fatalf("expected no episode for events 6s apart, got %d at event %d", n, i)
+		}
+	}
+}
+
+func TestTowDetector_Disabled(t *testing.T) {
+	d := newTowDetector()
+	d.setCount(2)
+	d.setEnabled(false)
+
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		if n := d.record(now); n != 0 {
+			t.Fatalf("expected no episode while disabled, got %d", n)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package redis
+
+import (
+	"sync"
+	"time"
+)
+
+// Wheel tamper defaults. A scooter being pushed or towed turns the rear
+// wheel well past walking-the-scooter-off-the-stand speeds.
+const (
+	engineECUHash           = "engine-ecu"
+	defaultWheelSpeedKmh    = 3
+	defaultWheelMinDuration = 2 * time.Second
+	wheelRPMThreshold       = 50
+)
+
+// wheelTamperDetector turns engine-ECU speed/RPM readings into tamper
+// signals: fire is called once the wheel has been over the threshold for
+// minDuration, and again every minDuration while it keeps turning. Safe for
+// concurrent use.
+type wheelTamperDetector struct {
+	mu          sync.Mutex
+	enabled     bool
+	speedKmh    int
+	minDuration time.Duration
+	speed       int
+	rpm         int
+	timer       *time.Timer
+	generation  int // identifies the current timer
+	fire        func(speed, rpm int)
+}
+
+func newWheelTamperDetector(fire func(speed, rpm int)) *wheelTamperDetector {
+	return &wheelTamperDetector{
+		enabled:     true,
+		speedKmh:    defaultWheelSpeedKmh,
+		minDuration: defaultWheelMinDuration,
+		fire:        fire,
+	}
+}
+
+// setEnabled turns detection on or off.
+func (d *wheelTamperDetector) setEnabled(enabled bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.enabled = enabled
+	d.evaluateUnsafe()
+}
+
+// setThreshold sets the speed threshold in km/h.
+func (d *wheelTamperDetector) setThreshold(speedKmh int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.speedKmh = speedKmh
+	d.evaluateUnsafe()
+}
+
+// setMinDuration sets how long the wheel must keep turning.
+func (d *wheelTamperDetector) setMinDuration(minDuration time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.minDuration = minDuration
+}
+
+// setSpeed records the latest ECU speed in km/h.
+func (d *wheelTamperDetector) setSpeed(speed int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.speed = speed
+	d.evaluateUnsafe()
+}
+
+// setRPM records the latest motor RPM.
+func (d *wheelTamperDetector) setRPM(rpm int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.rpm = rpm
+	d.evaluateUnsafe()
+}
+
+// stop cancels a pending tamper signal.
+func (d *wheelTamperDetector) stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+}
+
+func (d *wheelTamperDetector) turningUnsafe() bool {
+	return d.enabled && (d.speed > d.speedKmh || d.rpm > wheelRPMThreshold)
+}
+
+// evaluateUnsafe starts the sustain timer when the wheel starts turning and
+// cancels it when it stops.
+func (d *wheelTamperDetector) evaluateUnsafe() {
+	if !d.turningUnsafe() {
+		if d.timer != nil {
+			d.timer.Stop()
+			d.timer = nil
+		}
+		return
+	}
+	if d.timer == nil {
+		d.startTimerUnsafe()
+	}
+}
+
+func (d *wheelTamperDetector) startTimerUnsafe() {
+	d.generation++
+	generation := d.generation
+	d.timer = time.AfterFunc(d.minDuration, func() { d.expired(generation) })
+}
+
+func (d *wheelTamperDetector) expired(generation int) {
+	d.mu.Lock()
+	// A stop that lost the race against this timer already replaced or
+	// cleared it.
+	if d.timer == nil || d.generation != generation {
+		d.mu.Unlock()
+		return
+	}
+	if !d.turningUnsafe() {
+		d.timer = nil
+		d.mu.Unlock()
+		return
+	}
+	speed, rpm := d.speed, d.rpm
+	d.startTimerUnsafe()
+	d.mu.Unlock()
+
+	d.fire(speed, rpm)
+}
@@ -0,0 +1,73 @@
+package redis
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestWheelDetector() (*wheelTamperDetector, chan int) {
+	fired := make(chan int, 10)
+	d := newWheelTamperDetector(func(speed, rpm int) { fired <- speed })
+	d.setMinDuration(20 * time.Millisecond)
+	return d, fired
+}
+
+func TestWheelTamperDetector_FiresWhenSustained(t *testing.T) {
+	d, fired := newTestWheelDetector()
+	defer d.stop()
+
+	d.setSpeed(5)
+
+	select {
+	case speed := <-fired:
+		if speed != 5 {
+			t.Errorf("expected speed 5, got %d", speed)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected tamper to fire")
+	}
+
+	// Keeps firing while the wheel keeps turning.
+	select {
+	case <-fired:
+	case <-time.After(time.Second):
+		t.Fatal("expected tamper to fire again")
+	}
+}
+
+func TestWheelTamperDetector_IgnoresShortBursts(t *testing.T) {
+	d, fired := newTestWheelDetector()
+	defer d.stop()
+
+	d.setSpeed(5)
+	d.setSpeed(0)
+
+	select {
+	case <-fired:
+		t.Fatal("expected no tamper for a short burst")
+	case <-time.After(60 * time.Millisecond):
+	}
+}
+
+func TestWheelTamperDetector_Thresholds(t *testing.T) {
+	d, fired := newTestWheelDetector()
+	defer d.stop()
+
+	d.setSpeed(defaultWheelSpeedKmh)
+	d.setRPM(wheelRPMThreshold)
+
+	select {
+	case <-fired:
+		t.Fatal("expected no tamper at the threshold")
+	case <-time.After(60 * time.Millisecond):
+	}
+
+	d.setEnabled(false)
+	d.setRPM(wheelRPMThreshold + 1)
+
+	select {
+	case <-fired:
+		t.Fatal("expected no tamper while disabled")
+	case <-time.After(60 * time.Millisecond):
+	}
+}
@@ -0,0 +1,166 @@
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"alarm-service/internal/clock"
+)
+
+// Action is what a schedule rule does when it fires.
+type Action string
+
+const (
+	// ActionEnable sets settings alarm.enabled=true.
+	ActionEnable Action = "enable"
+	// ActionDisable sets settings alarm.enabled=false.
+	ActionDisable Action = "disable"
+	// ActionArm runtime-arms without touching alarm.enabled.
+	ActionArm Action = "arm"
+	// ActionDisarm runtime-disarms without touching alarm.enabled.
+	ActionDisarm Action = "disarm"
+)
+
+// Rule fires Action at Minute (minutes since midnight) on the weekdays set
+// in Days, indexed by time.Weekday.
+type Rule struct {
+	Days   [7]bool
+	Minute int
+	Action Action
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ParseRules parses a semicolon-separated list of "<days> <HH:MM> <action>"
+// rules, e.g. "mon-fri 08:00 disable; mon-fri 20:00 enable; sat,sun 00:00 enable".
+// Days is "daily", a single day, a range ("mon-fri") or a comma-separated
+// list of either. An empty string yields no rules.
+func ParseRules(s string) ([]Rule, error) {
+	var rules []Rule
+	for _, part := range strings.Split(s, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		fields := strings.Fields(part)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("schedule rule %q: expected \"<days> <HH:MM> <action>\"", part)
+		}
+		days, err := parseDays(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("schedule rule %q: %w", part, err)
+		}
+		minute, err := clock.Parse(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("schedule rule %q: %w", part, err)
+		}
+		action := Action(strings.ToLower(fields[2]))
+		switch action {
+		case ActionEnable, ActionDisable, ActionArm, ActionDisarm:
+		default:
+			return nil, fmt.Errorf("schedule rule %q: unknown action %q", part, fields[2])
+		}
+		rules = append(rules, Rule{Days: days, Minute: minute, Action: action})
+	}
+	return rules, nil
+}
+
+func parseDays(s string) ([7]bool, error) {
+	var days [7]bool
+	s = strings.ToLower(s)
+	if s == "daily" || s == "*" {
+		for i := range days {
+			days[i] = true
+		}
+		return days, nil
+	}
+	for _, item := range strings.Split(s, ",") {
+		from, to, isRange := strings.Cut(item, "-")
+		start, ok := weekdays[from]
+		if !ok {
+			return days, fmt.Errorf("unknown weekday %q", from)
+		}
+		if !isRange {
+			days[start] = true
+			continue
+		}
+		end, ok := weekdays[to]
+		if !ok {
+			return days, fmt.Errorf("unknown weekday %q", to)
+		}
+		for d := start; ; d = (d + 1) % 7 {
+			days[d] = true
+			if d == end {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+// Fire is one occurrence of a rule.
+type Fire struct {
+	At     time.Time
+	Action Action
+}
+
+// next returns the first rule occurrence strictly after now, in loc. The
+// zero Fire means there are no rules.
+func next(rules []Rule, now time.Time, loc *time.Location) Fire {
+	t := now.In(loc)
+	var best Fire
+	for day := 0; day <= 7; day++ {
+		date := t.AddDate(0, 0, day)
+		for _, r := range rules {
+			if !r.Days[date.Weekday()] {
+				continue
+			}
+			at := time.Date(date.Year(), date.Month(), date.Day(), r.Minute/60, r.Minute%60, 0, 0, loc)
+			if !at.After(t) {
+				continue
+			}
+			if best.At.IsZero() || at.Before(best.At) {
+				best = Fire{At: at, Action: r.Action}
+			}
+		}
+		if !best.At.IsZero() {
+			return best
+		}
+	}
+	return best
+}
+
+// previous returns the last rule occurrence at or before now, in loc. The
+// zero Fire means there are no rules.
+func previous(rules []Rule, now time.Time, loc *time.Location) Fire {
+	t := now.In(loc)
+	var best Fire
+	for day := 0; day <= 7; day++ {
+		date := t.AddDate(0, 0, -day)
+		for _, r := range rules {
+			if !r.Days[date.Weekday()] {
+				continue
+			}
+			at := time.Date(date.Year(), date.Month(), date.Day(), r.Minute/60, r.Minute%60, 0, 0, loc)
+			if at.After(t) {
+				continue
+			}
+			if best.At.IsZero() || at.After(best.At) {
+				best = Fire{At: at, Action: r.Action}
+			}
+		}
+		if !best.At.IsZero() {
+			return best
+		}
+	}
+	return best
+}
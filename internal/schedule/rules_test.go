@@ -0,0 +1,64 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules("mon-fri 08:00 disable; mon-fri 20:00 enable; sat,sun 00:00 enable")
+	if err != nil {
+		t.Fatalf("ParseRules failed: %v", err)
+	}
+	if len(rules) != 3 {
+		t.Fatalf("expected 3 rules, got %d", len(rules))
+	}
+
+	weekdaysOnly := [7]bool{false, true, true, true, true, true, false}
+	if rules[0].Days != weekdaysOnly || rules[0].Minute != 8*60 || rules[0].Action != ActionDisable {
+		t.Errorf("unexpected first rule: %+v", rules[0])
+	}
+	weekend := [7]bool{true, false, false, false, false, false, true}
+	if rules[2].Days != weekend || rules[2].Minute != 0 || rules[2].Action != ActionEnable {
+		t.Errorf("unexpected third rule: %+v", rules[2])
+	}
+
+	wrapped, err := ParseRules("fri-mon 12:00 arm")
+	if err != nil {
+		t.Fatalf("ParseRules failed: %v", err)
+	}
+	if wrapped[0].Days != [7]bool{true, true, false, false, false, true, true} {
+		t.Errorf("expected fri-mon to wrap over the weekend, got %v", wrapped[0].Days)
+	}
+
+	for _, bad := range []string{"mon 08:00", "xyz 08:00 enable", "mon 24:00 enable", "mon 08:00 explode"} {
+		if _, err := ParseRules(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestNextAndPrevious(t *testing.T) {
+	rules, _ := ParseRules("mon-fri 08:00 disable; mon-fri 20:00 enable")
+	loc := time.UTC
+
+	// Friday 21:00 → next is Monday 08:00, previous is Friday 20:00.
+	now := time.Date(2026, 10, 16, 21, 0, 0, 0, loc)
+	if now.Weekday() != time.Friday {
+		t.Fatalf("test date is not a Friday: %v", now.Weekday())
+	}
+
+	n := next(rules, now, loc)
+	if !n.At.Equal(time.Date(2026, 10, 19, 8, 0, 0, 0, loc)) || n.Action != ActionDisable {
+		t.Errorf("unexpected next fire: %+v", n)
+	}
+
+	p := previous(rules, now, loc)
+	if !p.At.Equal(time.Date(2026, 10, 16, 20, 0, 0, 0, loc)) || p.Action != ActionEnable {
+		t.Errorf("unexpected previous fire: %+v", p)
+	}
+
+	if f := next(nil, now, loc); !f.At.IsZero() {
+		t.Errorf("expected no fire without rules, got %+v", f)
+	}
+}
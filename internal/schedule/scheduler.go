@@ -0,0 +1,261 @@
+package schedule
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	ipc "github.com/librescoot/redis-ipc"
+)
+
+// Commander handles runtime arm/disarm requests that bypass the settings hash.
+type Commander interface {
+	RuntimeArm()
+	RuntimeDisarm()
+}
+
+// hashStore is the part of ipc.HashPublisher the scheduler writes through.
+type hashStore interface {
+	Set(field string, value any, opts ...ipc.SetOption) error
+	SetMany(fields map[string]any, opts ...ipc.SetOption) error
+	GetAll() (map[string]string, error)
+}
+
+// stopper is a pending timer.
+type stopper interface {
+	Stop() bool
+}
+
+// Scheduler enables/disables (or runtime arms/disarms) the alarm at the
+// weekday/time boundaries configured in settings alarm.schedule, so depots
+// don't need an operator pushing enable/disable onto scooter:alarm.
+//
+// The next fire time is kept in the alarm hash (schedule-next-fire /
+// schedule-next-action), which doubles as persistence: if the previous
+// instance was down when a rule should have fired, the most recent missed
+// rule is applied on startup.
+type Scheduler struct {
+	settingsWatcher *ipc.HashWatcher
+	settingsPub     hashStore
+	alarmPub        hashStore
+	commander       Commander
+	log             *slog.Logger
+	now             func() time.Time
+	afterFunc       func(d time.Duration, f func()) stopper
+	mu              sync.Mutex
+	rules           []Rule
+	loc             *time.Location
+	timer           stopper
+	pending         Fire
+	started         bool
+	done            chan struct{}
+}
+
+func afterFunc(d time.Duration, f func()) stopper {
+	return time.AfterFunc(d, f)
+}
+
+// New creates a Scheduler on the given redis-ipc client (StringCodec).
+func New(client *ipc.Client, commander Commander, log *slog.Logger) *Scheduler {
+	s := &Scheduler{
+		settingsWatcher: client.NewHashWatcher("settings"),
+		settingsPub:     client.NewHashPublisher("settings"),
+		alarmPub:        client.NewHashPublisher("alarm"),
+		commander:       commander,
+		log:             log,
+		now:             time.Now,
+		afterFunc:       afterFunc,
+		loc:             time.Local,
+		done:            make(chan struct{}),
+	}
+
+	s.settingsWatcher.OnField("alarm.schedule", s.setRules)
+	s.settingsWatcher.OnField("alarm.schedule-timezone", s.setTimezone)
+
+	return s
+}
+
+// setRules handles settings alarm.schedule.
+func (s *Scheduler) setRules(spec string) error {
+	rules, err := ParseRules(spec)
+	if err != nil {
+		s.log.Error("invalid alarm.schedule value", "value", spec, "error", err)
+		return nil
+	}
+	s.log.Info("alarm schedule changed", "rules", len(rules))
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rules = rules
+	if s.started {
+		s.rescheduleUnsafe()
+	}
+	return nil
+}
+
+// setTimezone handles settings alarm.schedule-timezone.
+func (s *Scheduler) setTimezone(tz string) error {
+	loc := time.Local
+	if tz != "" {
+		var err error
+		if loc, err = time.LoadLocation(tz); err != nil {
+			s.log.Error("invalid alarm.schedule-timezone value", "value", tz, "error", err)
+			return nil
+		}
+	}
+	s.log.Info("alarm schedule timezone changed", "timezone", loc.String())
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loc = loc
+	if s.started {
+		s.rescheduleUnsafe()
+	}
+	return nil
+}
+
+// Start loads the rules and, once ready is closed, catches up on a rule
+// missed while alarm-service was down and schedules the next one. ready
+// should close once the FSM has left init: a runtime arm or disarm sent
+// before that would be lost.
+func (s *Scheduler) Start(ready <-chan struct{}) error {
+	persisted := s.loadPersisted()
+
+	if err := s.settingsWatcher.StartWithSync(); err != nil {
+		return fmt.Errorf("failed to start schedule settings watcher: %w", err)
+	}
+
+	go s.catchUpWhenReady(ready, persisted)
+	return nil
+}
+
+// catchUpWhenReady runs catchUp once ready is closed, unless the scheduler
+// is stopped first.
+func (s *Scheduler) catchUpWhenReady(ready <-chan struct{}, persisted Fire) {
+	select {
+	case <-ready:
+	case <-s.done:
+		return
+	}
+	s.catchUp(persisted)
+}
+
+// catchUp applies the most recent rule missed since persisted was due, then
+// starts scheduling.
+func (s *Scheduler) catchUp(persisted Fire) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if !persisted.At.IsZero() && !persisted.At.After(now) {
+		missed := previous(s.rules, now, s.loc)
+		if !missed.At.IsZero() && !missed.At.Before(persisted.At) {
+			s.log.Info("applying schedule rule missed while stopped",
+				"action", missed.Action, "due", missed.At)
+			s.applyUnsafe(missed)
+		}
+	}
+
+	s.started = true
+	s.rescheduleUnsafe()
+}
+
+// Stop stops the watcher and the pending timer.
+func (s *Scheduler) Stop() {
+	close(s.done)
+	s.settingsWatcher.Stop()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+}
+
+// loadPersisted reads the next fire the previous instance had scheduled.
+func (s *Scheduler) loadPersisted() Fire {
+	fields, err := s.alarmPub.GetAll()
+	if err != nil {
+		s.log.Warn("failed to read persisted schedule", "error", err)
+		return Fire{}
+	}
+	ts, err := strconv.ParseInt(fields["schedule-next-fire"], 10, 64)
+	if err != nil {
+		return Fire{}
+	}
+	return Fire{At: time.Unix(ts, 0), Action: Action(fields["schedule-next-action"])}
+}
+
+// rescheduleUnsafe arms the timer for the next rule and publishes it.
+func (s *Scheduler) rescheduleUnsafe() {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+
+	now := s.now()
+	s.pending = next(s.rules, now, s.loc)
+
+	nextFire := ""
+	if !s.pending.At.IsZero() {
+		nextFire = strconv.FormatInt(s.pending.At.Unix(), 10)
+		f := s.pending
+		s.timer = s.afterFunc(f.At.Sub(now), func() { s.fire(f) })
+		s.log.Info("next scheduled alarm change", "action", s.pending.Action, "at", s.pending.At)
+	}
+
+	if err := s.alarmPub.SetMany(map[string]any{
+		"schedule-next-fire":   nextFire,
+		"schedule-next-action": string(s.pending.Action),
+	}); err != nil {
+		s.log.Error("failed to publish schedule", "error", err)
+	}
+}
+
+// fire applies f, the rule its timer was armed for, and schedules the next
+// one. Stopping a timer doesn't stop a callback already waiting on the
+// mutex, so a rule that was rescheduled or stopped in the meantime is
+// skipped rather than applying whatever is pending now.
+func (s *Scheduler) fire(f Fire) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-s.done:
+		return
+	default:
+	}
+	if !f.At.Equal(s.pending.At) || f.Action != s.pending.Action {
+		s.log.Debug("skipping superseded schedule timer", "action", f.Action, "at", f.At)
+		return
+	}
+	s.log.Info("scheduled alarm change", "action", f.Action)
+	s.applyUnsafe(f)
+	s.rescheduleUnsafe()
+}
+
+// applyUnsafe carries out a rule's action and records it as the last fire.
+func (s *Scheduler) applyUnsafe(f Fire) {
+	switch f.Action {
+	case ActionEnable:
+		if err := s.settingsPub.Set("alarm.enabled", "true"); err != nil {
+			s.log.Error("failed to enable alarm", "error", err)
+		}
+	case ActionDisable:
+		if err := s.settingsPub.Set("alarm.enabled", "false"); err != nil {
+			s.log.Error("failed to disable alarm", "error", err)
+		}
+	case ActionArm:
+		s.commander.RuntimeArm()
+	case ActionDisarm:
+		s.commander.RuntimeDisarm()
+	default:
+		s.log.Warn("ignoring unknown schedule action", "action", f.Action)
+		return
+	}
+
+	if err := s.alarmPub.SetMany(map[string]any{
+		"schedule-last-fire":   s.now().Unix(),
+		"schedule-last-action": string(f.Action),
+	}); err != nil {
+		s.log.Error("failed to publish schedule", "error", err)
+	}
+}
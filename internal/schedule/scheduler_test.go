@@ -0,0 +1,249 @@
+package schedule
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"testing"
+	"time"
+
+	ipc "github.com/librescoot/redis-ipc"
+)
+
+// fakeHash is an in-memory hashStore.
+type fakeHash map[string]string
+
+func (h fakeHash) Set(field string, value any, _ ...ipc.SetOption) error {
+	h[field] = fmt.Sprint(value)
+	return nil
+}
+
+func (h fakeHash) SetMany(fields map[string]any, _ ...ipc.SetOption) error {
+	for field, value := range fields {
+		h[field] = fmt.Sprint(value)
+	}
+	return nil
+}
+
+func (h fakeHash) GetAll() (map[string]string, error) {
+	return h, nil
+}
+
+type fakeCommander struct {
+	arms, disarms int
+}
+
+func (c *fakeCommander) RuntimeArm()    { c.arms++ }
+func (c *fakeCommander) RuntimeDisarm() { c.disarms++ }
+
+// fakeTimer records what the scheduler armed instead of waiting for it.
+type fakeTimer struct {
+	after   time.Duration
+	fn      func()
+	stopped bool
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.stopped = true
+	return true
+}
+
+type testScheduler struct {
+	*Scheduler
+	settings  fakeHash
+	alarm     fakeHash
+	commander *fakeCommander
+	clock     time.Time
+	timers    []*fakeTimer
+}
+
+func newTestScheduler(t *testing.T, now time.Time, spec string) *testScheduler {
+	t.Helper()
+	ts := &testScheduler{
+		settings:  fakeHash{},
+		alarm:     fakeHash{},
+		commander: &fakeCommander{},
+		clock:     now,
+	}
+	ts.Scheduler = &Scheduler{
+		settingsPub: ts.settings,
+		alarmPub:    ts.alarm,
+		commander:   ts.commander,
+		log:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+		now:         func() time.Time { return ts.clock },
+		afterFunc: func(d time.Duration, f func()) stopper {
+			timer := &fakeTimer{after: d, fn: f}
+			ts.timers = append(ts.timers, timer)
+			return timer
+		},
+		loc:  time.UTC,
+		done: make(chan struct{}),
+	}
+	if err := ts.setRules(spec); err != nil {
+		t.Fatal(err)
+	}
+	return ts
+}
+
+func (ts *testScheduler) lastTimer(t *testing.T) *fakeTimer {
+	t.Helper()
+	if len(ts.timers) == 0 {
+		t.Fatal("expected a timer")
+	}
+	return ts.timers[len(ts.timers)-1]
+}
+
+// Monday 2026-10-19.
+var monday = time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+
+func TestScheduler_SchedulesAndPersistsNextFire(t *testing.T) {
+	ts := newTestScheduler(t, monday.Add(7*time.Hour), "daily 08:00 disable; daily 20:00 enable")
+
+	ts.catchUp(Fire{})
+
+	timer := ts.lastTimer(t)
+	if timer.after != time.Hour {
+		t.Errorf("expected timer in 1h, got %v", timer.after)
+	}
+	want := strconv.FormatInt(monday.Add(8*time.Hour).Unix(), 10)
+	if ts.alarm["schedule-next-fire"] != want || ts.alarm["schedule-next-action"] != "disable" {
+		t.Errorf("expected next fire %s disable, got %q %q",
+			want, ts.alarm["schedule-next-fire"], ts.alarm["schedule-next-action"])
+	}
+}
+
+func TestScheduler_FireAppliesAndRearms(t *testing.T) {
+	ts := newTestScheduler(t, monday.Add(7*time.Hour), "daily 08:00 disable; daily 20:00 enable")
+	ts.catchUp(Fire{})
+
+	ts.clock = monday.Add(8 * time.Hour)
+	ts.lastTimer(t).fn()
+
+	if ts.settings["alarm.enabled"] != "false" {
+		t.Errorf("expected disable to write alarm.enabled=false, got %q", ts.settings["alarm.enabled"])
+	}
+	if ts.alarm["schedule-last-action"] != "disable" ||
+		ts.alarm["schedule-last-fire"] != strconv.FormatInt(ts.clock.Unix(), 10) {
+		t.Errorf("expected last fire recorded, got %v", ts.alarm)
+	}
+	if timer := ts.lastTimer(t); timer.after != 12*time.Hour || ts.alarm["schedule-next-action"] != "enable" {
+		t.Errorf("expected re-armed for 20:00 enable, got %v %q", timer.after, ts.alarm["schedule-next-action"])
+	}
+}
+
+func TestScheduler_RuntimeActionsUseCommander(t *testing.T) {
+	ts := newTestScheduler(t, monday, "daily 01:00 arm; daily 02:00 disarm")
+	ts.catchUp(Fire{})
+
+	ts.clock = monday.Add(time.Hour)
+	ts.lastTimer(t).fn()
+	ts.clock = monday.Add(2 * time.Hour)
+	ts.lastTimer(t).fn()
+
+	if ts.commander.arms != 1 || ts.commander.disarms != 1 {
+		t.Errorf("expected one arm and one disarm, got %+v", ts.commander)
+	}
+	if _, ok := ts.settings["alarm.enabled"]; ok {
+		t.Error("expected runtime actions to leave alarm.enabled alone")
+	}
+}
+
+func TestScheduler_CatchUpAppliesMissedRule(t *testing.T) {
+	ts := newTestScheduler(t, monday.Add(9*time.Hour), "daily 08:00 disable; daily 20:00 enable")
+
+	// The previous instance expected to fire at 08:00 and was down.
+	ts.catchUp(Fire{At: monday.Add(8 * time.Hour), Action: ActionDisable})
+
+	if ts.settings["alarm.enabled"] != "false" {
+		t.Errorf("expected missed disable applied, got %q", ts.settings["alarm.enabled"])
+	}
+	if ts.alarm["schedule-next-action"] != "enable" {
+		t.Errorf("expected next action enable, got %q", ts.alarm["schedule-next-action"])
+	}
+}
+
+func TestScheduler_CatchUpSkipsFutureFire(t *testing.T) {
+	ts := newTestScheduler(t, monday.Add(7*time.Hour), "daily 08:00 disable; daily 20:00 enable")
+
+	ts.catchUp(Fire{At: monday.Add(8 * time.Hour), Action: ActionDisable})
+
+	if _, ok := ts.settings["alarm.enabled"]; ok {
+		t.Error("expected nothing applied before the persisted fire is due")
+	}
+}
+
+func TestScheduler_RulesChangeReschedules(t *testing.T) {
+	ts := newTestScheduler(t, monday.Add(7*time.Hour), "daily 08:00 disable")
+	ts.catchUp(Fire{})
+	first := ts.lastTimer(t)
+
+	if err := ts.setRules(""); err != nil {
+		t.Fatal(err)
+	}
+	if !first.stopped {
+		t.Error("expected the old timer stopped")
+	}
+	if ts.alarm["schedule-next-fire"] != "" {
+		t.Errorf("expected no next fire without rules, got %q", ts.alarm["schedule-next-fire"])
+	}
+}
+
+func TestScheduler_SupersededTimerSkipped(t *testing.T) {
+	ts := newTestScheduler(t, monday.Add(7*time.Hour), "daily 08:00 disable")
+	ts.catchUp(Fire{})
+	first := ts.lastTimer(t)
+
+	// The rules change while the old timer's callback is already waiting
+	// on the mutex: it must not apply the new rule hours early.
+	ts.clock = monday.Add(8 * time.Hour)
+	if err := ts.setRules("daily 20:00 enable"); err != nil {
+		t.Fatal(err)
+	}
+	first.fn()
+
+	if len(ts.settings) != 0 {
+		t.Errorf("expected no change from a superseded timer, got %v", ts.settings)
+	}
+	if ts.alarm["schedule-next-action"] != "enable" {
+		t.Errorf("expected the new rule to stay pending, got %q", ts.alarm["schedule-next-action"])
+	}
+}
+
+func TestScheduler_CatchUpWaitsForReady(t *testing.T) {
+	ts := newTestScheduler(t, monday.Add(9*time.Hour), "daily 08:00 disable")
+	ready := make(chan struct{})
+	finished := make(chan struct{})
+
+	go func() {
+		ts.catchUpWhenReady(ready, Fire{At: monday.Add(8 * time.Hour), Action: ActionDisable})
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		t.Fatal("expected catch-up to wait for ready")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(ready)
+	<-finished
+	if ts.settings["alarm.enabled"] != "false" {
+		t.Error("expected missed rule applied once ready")
+	}
+}
+
+func TestScheduler_StopBeforeReady(t *testing.T) {
+	ts := newTestScheduler(t, monday.Add(9*time.Hour), "daily 08:00 disable")
+	finished := make(chan struct{})
+
+	go func() {
+		ts.catchUpWhenReady(make(chan struct{}), Fire{At: monday.Add(8 * time.Hour), Action: ActionDisable})
+		close(finished)
+	}()
+	close(ts.done)
+	<-finished
+
+	if _, ok := ts.settings["alarm.enabled"]; ok || ts.started {
+		t.Error("expected nothing applied after stop")
+	}
+}